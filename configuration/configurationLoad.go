@@ -14,15 +14,67 @@ type Configuration struct {
 	Profile        string
 	Kafka 		 kafka
 	Rdb          rdb
+	Grpc         grpc
+	Http         http
+	Tracing      tracing
+	Status       status
+}
+
+type status struct {
+	Apply []string
+	Skip []string
+	Review []string
+}
+
+type tracing struct {
+	Exporter string
+	Endpoint string
+	Insecure bool
+	Servicename string
+	Samplingratio float64
+}
+
+type http struct {
+	Enabled bool
+	Port int
+	Shutdowntimeout int
+}
+
+type grpc struct {
+	Enabled bool
+	Port int
+	Subscriberbuffer int
 }
 
 type rdb struct {
+	Uri    string
 	Host    string
+	Port    int
+	Srv    bool
+	Replicaset    string
+	Tls    bool
+	Tlscafile    string
+	Tlscertfile    string
+	Authsource    string
+	Authmechanism    string
+	Readconcern    string
+	Writeconcern    string
+	Appname    string
 	Database    string
 	Username string
 	Password    string
 	Poolsize  int
 	Timeout   int
+	Maxattempts int
+	Retrybasedelay int
+	Retrymaxdelay int
+	Insertmode string
+	Updatemode string
+	Deletemode string
+	Idmode string
+	Pagesize int
+	Maxpagesize int
+	Historycollection string
 }
 
 type kafka struct {
@@ -37,6 +89,19 @@ type kafka struct {
 	Gitactionbacktopic string
 	Messageminsize int
 	Messagemaxsize int
+	Workers int
+	Workerqueuesize int
+	Deadlettertopic string
+	Shutdowntimeout int
+	Heartbeatinterval int
+	Progresstimeout int
+	Reviewtopic string
+	Highpriorityweight int
+	Mediumpriorityweight int
+	Lowpriorityweight int
+	Prioritymaxwait int
+	Batchsize int
+	Batchwait int
 }
 
 
@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	rdb "xqledger/rdboperator/mongodb"
+	pb "xqledger/rdboperator/protobuf"
+	utils "xqledger/rdboperator/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetRecord reads a record from the RDB.
+func (s *recordServer) GetRecord(ctx context.Context, request *pb.GetRecordRequest) (*pb.Record, error) {
+	record, err := rdb.GetRecord(ctx, request.GetDbname(), request.GetGroup(), request.GetId())
+	if err != nil {
+		return nil, toStatus(err, "GetRecord", fmt.Sprintf("Database '%s' - Group '%s' - ID '%s'", request.GetDbname(), request.GetGroup(), request.GetId()))
+	}
+	return toRecord(record), nil
+}
+
+// ListRecords reads a page of the records of a group.
+func (s *recordServer) ListRecords(ctx context.Context, request *pb.ListRecordsRequest) (*pb.RecordPage, error) {
+	page, err := rdb.ListRecords(ctx, request.GetDbname(), request.GetGroup(), int(request.GetPageSize()), request.GetPageToken())
+	if err != nil {
+		return nil, toStatus(err, "ListRecords", fmt.Sprintf("Database '%s' - Group '%s'", request.GetDbname(), request.GetGroup()))
+	}
+	return toRecordPage(page), nil
+}
+
+// QueryRecords reads a page of the records of a group that match a filter expression.
+func (s *recordServer) QueryRecords(ctx context.Context, request *pb.QueryRecordsRequest) (*pb.RecordPage, error) {
+	page, err := rdb.QueryRecords(ctx, request.GetDbname(), request.GetGroup(), request.GetFilter(), int(request.GetPageSize()), request.GetPageToken())
+	if err != nil {
+		return nil, toStatus(err, "QueryRecords", fmt.Sprintf("Database '%s' - Group '%s' - Filter '%s'", request.GetDbname(), request.GetGroup(), request.GetFilter()))
+	}
+	return toRecordPage(page), nil
+}
+
+// toStatus maps the errors of the mongodb package to gRPC codes, so that clients can
+// tell bad requests and missing records from RDB failures.
+func toStatus(err error, methodMsg string, request string) error {
+	switch {
+	case errors.Is(err, rdb.ErrRecordNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, rdb.ErrMissingDatabase), errors.Is(err, rdb.ErrMissingID), errors.Is(err, rdb.ErrInvalidID),
+		errors.Is(err, rdb.ErrInvalidFilter), errors.Is(err, rdb.ErrInvalidPageToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	utils.PrintLogError(err, componentMessage, methodMsg, "Read failed - "+request)
+	if rdb.IsTransientError(err) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toRecord(record rdb.Record) *pb.Record {
+	return &pb.Record{
+		Id:      record.ID,
+		Group:   record.Group,
+		Dbname:  record.DBName,
+		Content: record.Content,
+	}
+}
+
+func toRecordPage(page rdb.RecordPage) *pb.RecordPage {
+	result := &pb.RecordPage{NextPageToken: page.NextPageToken}
+	for _, record := range page.Records {
+		result.Records = append(result.Records, toRecord(record))
+	}
+	return result
+}
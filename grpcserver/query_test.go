@@ -0,0 +1,32 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	rdb "xqledger/rdboperator/mongodb"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	Convey("Check read errors are mapped to gRPC codes ", t, func() {
+		So(status.Code(toStatus(rdb.ErrRecordNotFound, "GetRecord", "")), ShouldEqual, codes.NotFound)
+		So(status.Code(toStatus(rdb.ErrMissingDatabase, "GetRecord", "")), ShouldEqual, codes.InvalidArgument)
+		So(status.Code(toStatus(fmt.Errorf("%w: bad", rdb.ErrInvalidFilter), "QueryRecords", "")), ShouldEqual, codes.InvalidArgument)
+		So(status.Code(toStatus(rdb.ErrInvalidPageToken, "ListRecords", "")), ShouldEqual, codes.InvalidArgument)
+		So(status.Code(toStatus(errors.New("boom"), "ListRecords", "")), ShouldEqual, codes.Internal)
+	})
+
+	Convey("Check a page is converted with its next page token ", t, func() {
+		page := toRecordPage(rdb.RecordPage{
+			Records:       []rdb.Record{{ID: "1", DBName: "TestRepository", Group: "main", Content: `{"a":1}`}},
+			NextPageToken: "next",
+		})
+		So(page.NextPageToken, ShouldEqual, "next")
+		So(len(page.Records), ShouldEqual, 1)
+		So(page.Records[0].Content, ShouldEqual, `{"a":1}`)
+	})
+}
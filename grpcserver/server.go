@@ -0,0 +1,97 @@
+package grpcserver
+
+import (
+	"fmt"
+	"net"
+	configuration "xqledger/rdboperator/configuration"
+	pb "xqledger/rdboperator/protobuf"
+	"xqledger/rdboperator/recordfeed"
+	utils "xqledger/rdboperator/utils"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const componentMessage = "gRPC Record Service"
+
+var config = configuration.GlobalConfiguration
+
+type recordServer struct {
+	pb.UnimplementedRecordServiceServer
+}
+
+// GetRDBRecordsStream sends every change applied to the RDB for the requested
+// database and group, one RecordSet per change, until the client goes away or the
+// server stops. Clients that cannot keep up are disconnected with ResourceExhausted.
+func (s *recordServer) GetRDBRecordsStream(filter *pb.RecordFilter, stream pb.RecordService_GetRDBRecordsStreamServer) error {
+	methodMsg := "GetRDBRecordsStream"
+	subscription := recordfeed.Subscribe(filter.GetDbname(), filter.GetGroup(), config.Grpc.Subscriberbuffer)
+	defer subscription.Close()
+	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Stream opened - Database '%s' - Group '%s'", filter.GetDbname(), filter.GetGroup()))
+	for {
+		select {
+		case <-stream.Context().Done():
+			utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Stream closed - Database '%s' - Group '%s'", filter.GetDbname(), filter.GetGroup()))
+			return nil
+		case change, open := <-subscription.Changes:
+			if !open {
+				droppedErr := status.Error(codes.ResourceExhausted, "stream dropped, client not keeping up with the changes")
+				utils.PrintLogWarn(droppedErr, componentMessage, methodMsg, fmt.Sprintf("Stream dropped - Database '%s' - Group '%s'", filter.GetDbname(), filter.GetGroup()))
+				return droppedErr
+			}
+			sendErr := stream.Send(toRecordSet(change))
+			if sendErr != nil {
+				utils.PrintLogError(sendErr, componentMessage, methodMsg, "Send output message to stream failed")
+				return sendErr
+			}
+		}
+	}
+}
+
+func toRecordSet(change recordfeed.Change) *pb.RecordSet {
+	event := change.Event
+	return &pb.RecordSet{
+		Records: []string{event.RecordContent},
+		Changes: []*pb.RecordChange{{
+			Id:            event.Id,
+			Group:         event.Group,
+			Dbname:        event.DBName,
+			User:          event.User,
+			OperationType: event.OperationType,
+			RecordContent: event.RecordContent,
+			AppliedTime:   change.AppliedTime,
+		}},
+	}
+}
+
+var server *grpc.Server
+
+// Start listens on Grpc.Port and serves the RecordService in the background.
+func Start() error {
+	methodMsg := "Start"
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Grpc.Port))
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, fmt.Sprintf("Listening on port %d failed", config.Grpc.Port))
+		return err
+	}
+	server = grpc.NewServer()
+	pb.RegisterRecordServiceServer(server, &recordServer{})
+	go func(server *grpc.Server) {
+		serveErr := server.Serve(listener)
+		if serveErr != nil {
+			utils.PrintLogError(serveErr, componentMessage, methodMsg, "Server stopped")
+		}
+	}(server)
+	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Serving RecordService on port %d", config.Grpc.Port))
+	return nil
+}
+
+// Stop closes the listener and every open stream. It is a no-op if the server was
+// not started.
+func Stop() {
+	if server != nil {
+		server.Stop()
+		utils.PrintLogInfo(componentMessage, "Stop", "Server stopped")
+	}
+}
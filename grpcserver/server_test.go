@@ -0,0 +1,24 @@
+package grpcserver
+
+import (
+	"testing"
+	"xqledger/rdboperator/recordfeed"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestToRecordSet(t *testing.T) {
+	Convey("Check an applied change is sent with its record location ", t, func() {
+		event := utils.RecordEvent{Id: "1", Group: "Users", DBName: "TestRepository", User: "me@xqledger.com", OperationType: "new", RecordContent: `{"name":"A"}`}
+		recordSet := toRecordSet(recordfeed.Change{Event: event, AppliedTime: 42})
+		So(recordSet.Records, ShouldResemble, []string{`{"name":"A"}`})
+		So(len(recordSet.Changes), ShouldEqual, 1)
+		change := recordSet.Changes[0]
+		So(change.Id, ShouldEqual, "1")
+		So(change.Group, ShouldEqual, "Users")
+		So(change.Dbname, ShouldEqual, "TestRepository")
+		So(change.OperationType, ShouldEqual, "new")
+		So(change.AppliedTime, ShouldEqual, 42)
+	})
+}
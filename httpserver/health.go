@@ -0,0 +1,42 @@
+package httpserver
+
+import (
+	"net/http"
+	"xqledger/rdboperator/kafka"
+	rdb "xqledger/rdboperator/mongodb"
+)
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// readinessChecks are run by /readyz.
+var (
+	checkRDB      = func(r *http.Request) error { return rdb.Ping(r.Context()) }
+	checkConsumer = func(r *http.Request) error { return kafka.Ready() }
+)
+
+// healthz serves GET /healthz: the process is alive as long as it can answer.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// readyz serves GET /readyz: the operator is ready when the RDB answers a ping and
+// the consumer has joined its group and keeps making progress.
+func readyz(w http.ResponseWriter, r *http.Request) {
+	response := healthResponse{Status: "ok", Checks: map[string]string{"rdb": "ok", "kafka": "ok"}}
+	status := http.StatusOK
+	if err := checkRDB(r); err != nil {
+		response.Checks["rdb"] = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	if err := checkConsumer(r); err != nil {
+		response.Checks["kafka"] = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	if status != http.StatusOK {
+		response.Status = "unavailable"
+	}
+	writeJSON(w, status, response)
+}
@@ -0,0 +1,45 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHealthz(t *testing.T) {
+	Convey("Check liveness does not depend on the RDB or Kafka ", t, func() {
+		recorder := httptest.NewRecorder()
+		newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		So(recorder.Code, ShouldEqual, http.StatusOK)
+	})
+}
+
+func TestReadyz(t *testing.T) {
+	originalRDB, originalConsumer := checkRDB, checkConsumer
+	defer func() { checkRDB, checkConsumer = originalRDB, originalConsumer }()
+
+	Convey("Check the operator is ready when every check passes ", t, func() {
+		checkRDB = func(r *http.Request) error { return nil }
+		checkConsumer = func(r *http.Request) error { return nil }
+		recorder := httptest.NewRecorder()
+		newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		So(recorder.Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Check a failing check makes the operator unready ", t, func() {
+		checkRDB = func(r *http.Request) error { return nil }
+		checkConsumer = func(r *http.Request) error { return errors.New("consumer group not joined") }
+		recorder := httptest.NewRecorder()
+		newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		So(recorder.Code, ShouldEqual, http.StatusServiceUnavailable)
+		var response healthResponse
+		So(json.Unmarshal(recorder.Body.Bytes(), &response), ShouldBeNil)
+		So(response.Status, ShouldEqual, "unavailable")
+		So(response.Checks["rdb"], ShouldEqual, "ok")
+		So(response.Checks["kafka"], ShouldEqual, "consumer group not joined")
+	})
+}
@@ -0,0 +1,207 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	configuration "xqledger/rdboperator/configuration"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const componentMessage = "HTTP Record Service"
+
+var config = configuration.GlobalConfiguration
+
+// recordResponse is the JSON form of a record: the stored document as is, plus the
+// metadata of the last event applied to it.
+type recordResponse struct {
+	ID       string              `json:"id"`
+	DBName   string              `json:"dbname"`
+	Group    string              `json:"group"`
+	Document json.RawMessage     `json:"document"`
+	Metadata *rdb.RecordMetadata `json:"metadata,omitempty"`
+}
+
+type recordPageResponse struct {
+	Records       []recordResponse `json:"records"`
+	NextPageToken string           `json:"next_page_token,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+var server *http.Server
+
+// readRecord and readHistory read from the RDB.
+var readRecord = rdb.GetRecord
+var readHistory = rdb.GetRecordHistory
+
+func newHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", healthz)
+	mux.HandleFunc("GET /readyz", readyz)
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.HandleFunc("GET /dbs/{dbname}/groups/{group}/records", listRecords)
+	// Record IDs are Git file paths, so they take the rest of the path
+	mux.HandleFunc("GET /dbs/{dbname}/groups/{group}/records/{id...}", recordRoute)
+	return mux
+}
+
+// recordRoute serves a record, or its history when the path ends in /history. The
+// history of a record whose ID itself ends in /history is reached by escaping the
+// last slash of the ID as %2F.
+func recordRoute(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if strings.HasSuffix(id, "/history") && strings.HasSuffix(r.URL.EscapedPath(), "/history") {
+		getRecordHistory(w, r, strings.TrimSuffix(id, "/history"))
+		return
+	}
+	getRecord(w, r, id)
+}
+
+// Start listens on Http.Port and serves the read API, the health probes and the
+// metrics in the background.
+func Start() error {
+	methodMsg := "Start"
+	server = &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.Http.Port),
+		Handler:           newHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listenErr := make(chan error, 1)
+	go func(server *http.Server) {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.PrintLogError(err, componentMessage, methodMsg, "Server stopped")
+			listenErr <- err
+		}
+	}(server)
+	// Give the listener a moment so a port already in use is reported on startup
+	select {
+	case err := <-listenErr:
+		server = nil
+		return err
+	case <-time.After(100 * time.Millisecond):
+	}
+	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Serving read API on port %d", config.Http.Port))
+	return nil
+}
+
+// Stop waits for the requests in progress, up to Http.Shutdowntimeout seconds, and
+// closes the server. It is a no-op if the server was not started.
+func Stop() {
+	if server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Http.Shutdowntimeout)*time.Second)
+	defer cancel()
+	shutdownErr := server.Shutdown(ctx)
+	if shutdownErr != nil {
+		utils.PrintLogError(shutdownErr, componentMessage, "Stop", "Server not stopped gracefully")
+		return
+	}
+	utils.PrintLogInfo(componentMessage, "Stop", "Server stopped")
+}
+
+// getRecord serves GET /dbs/{dbname}/groups/{group}/records/{id...}
+func getRecord(w http.ResponseWriter, r *http.Request, id string) {
+	record, err := readRecord(r.Context(), r.PathValue("dbname"), r.PathValue("group"), id)
+	if err != nil {
+		writeError(w, err, "getRecord", r.URL.Path)
+		return
+	}
+	writeJSON(w, http.StatusOK, toRecordResponse(record))
+}
+
+// listRecords serves GET /dbs/{dbname}/groups/{group}/records with the optional query
+// parameters page_size, page_token and filter.
+func listRecords(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageSize := 0
+	if len(query.Get("page_size")) > 0 {
+		size, sizeErr := strconv.Atoi(query.Get("page_size"))
+		if sizeErr != nil || size < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse{"page_size must be a positive number"})
+			return
+		}
+		pageSize = size
+	}
+	var page rdb.RecordPage
+	var err error
+	if filter := query.Get("filter"); len(filter) > 0 {
+		page, err = rdb.QueryRecords(r.Context(), r.PathValue("dbname"), r.PathValue("group"), filter, pageSize, query.Get("page_token"))
+	} else {
+		page, err = rdb.ListRecords(r.Context(), r.PathValue("dbname"), r.PathValue("group"), pageSize, query.Get("page_token"))
+	}
+	if err != nil {
+		writeError(w, err, "listRecords", r.URL.String())
+		return
+	}
+	response := recordPageResponse{Records: []recordResponse{}, NextPageToken: page.NextPageToken}
+	for _, record := range page.Records {
+		response.Records = append(response.Records, toRecordResponse(record))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// getRecordHistory serves GET /dbs/{dbname}/groups/{group}/records/{id...}/history
+// with the status transitions of a record, oldest first.
+func getRecordHistory(w http.ResponseWriter, r *http.Request, id string) {
+	history, err := readHistory(r.Context(), r.PathValue("dbname"), r.PathValue("group"), id)
+	if err != nil {
+		writeError(w, err, "getRecordHistory", r.URL.Path)
+		return
+	}
+	if history == nil {
+		history = []rdb.StatusTransition{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+func toRecordResponse(record rdb.Record) recordResponse {
+	return recordResponse{
+		ID:       record.ID,
+		DBName:   record.DBName,
+		Group:    record.Group,
+		Document: json.RawMessage(record.Content),
+		Metadata: record.Metadata,
+	}
+}
+
+// writeError maps the errors of the mongodb package to HTTP status codes.
+func writeError(w http.ResponseWriter, err error, methodMsg string, request string) {
+	switch {
+	case errors.Is(err, rdb.ErrRecordNotFound), errors.Is(err, rdb.ErrHistoryDisabled):
+		writeJSON(w, http.StatusNotFound, errorResponse{err.Error()})
+	case errors.Is(err, rdb.ErrMissingDatabase), errors.Is(err, rdb.ErrMissingID), errors.Is(err, rdb.ErrInvalidID),
+		errors.Is(err, rdb.ErrInvalidFilter), errors.Is(err, rdb.ErrInvalidPageToken):
+		writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(w, http.StatusGatewayTimeout, errorResponse{err.Error()})
+	default:
+		utils.PrintLogError(err, componentMessage, methodMsg, "Read failed - "+request)
+		if rdb.IsTransientError(err) {
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, errorResponse{err.Error()})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encodeErr := json.NewEncoder(w).Encode(body)
+	if encodeErr != nil {
+		utils.PrintLogError(encodeErr, componentMessage, "writeJSON", "Error writing response")
+	}
+}
@@ -0,0 +1,94 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	rdb "xqledger/rdboperator/mongodb"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestToRecordResponse(t *testing.T) {
+	Convey("Check a record is rendered with its document and metadata ", t, func() {
+		record := rdb.Record{ID: "1", DBName: "TestRepository", Group: "Users", Content: `{"name":"A"}`,
+			Metadata: &rdb.RecordMetadata{Operation: "update", Status: "COMPLETE", User: "me@xqledger.com", ProcessingTime: 42, AppliedTime: 50, CorrelationID: "abc"}}
+		body, err := json.Marshal(toRecordResponse(record))
+		So(err, ShouldBeNil)
+		So(string(body), ShouldEqual, `{"id":"1","dbname":"TestRepository","group":"Users","document":{"name":"A"},"metadata":{"operation":"update","status":"COMPLETE","user":"me@xqledger.com","processing_time":42,"applied_time":50,"correlation_id":"abc"}}`)
+	})
+}
+
+func TestWriteError(t *testing.T) {
+	Convey("Check read errors are mapped to HTTP status codes ", t, func() {
+		for err, status := range map[error]int{
+			rdb.ErrRecordNotFound:                           http.StatusNotFound,
+			rdb.ErrMissingID:                                http.StatusBadRequest,
+			fmt.Errorf("%w: bad", rdb.ErrInvalidFilter):     http.StatusBadRequest,
+			fmt.Errorf("%w '1': bad hex", rdb.ErrInvalidID): http.StatusBadRequest,
+			errors.New("boom"):                              http.StatusInternalServerError,
+		} {
+			recorder := httptest.NewRecorder()
+			writeError(recorder, err, "TestWriteError", "/")
+			So(recorder.Code, ShouldEqual, status)
+			So(recorder.Header().Get("Content-Type"), ShouldEqual, "application/json")
+		}
+	})
+}
+
+func TestListRecordsPageSize(t *testing.T) {
+	Convey("Check an invalid page size is rejected before reading ", t, func() {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/dbs/TestRepository/groups/main/records?page_size=many", nil)
+		newHandler().ServeHTTP(recorder, request)
+		So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Check only GET is served ", t, func() {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/dbs/TestRepository/groups/main/records", nil)
+		newHandler().ServeHTTP(recorder, request)
+		So(recorder.Code, ShouldEqual, http.StatusMethodNotAllowed)
+	})
+}
+
+func TestRecordRoute(t *testing.T) {
+	defer func() { readRecord, readHistory = rdb.GetRecord, rdb.GetRecordHistory }()
+	var readID string
+	readRecord = func(ctx context.Context, dbName string, group string, id string) (rdb.Record, error) {
+		readID = "record:" + id
+		return rdb.Record{ID: id, DBName: dbName, Group: group, Content: "{}"}, nil
+	}
+	readHistory = func(ctx context.Context, dbName string, group string, id string) ([]rdb.StatusTransition, error) {
+		readID = "history:" + id
+		return nil, nil
+	}
+	serve := func(path string) int {
+		recorder := httptest.NewRecorder()
+		newHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code
+	}
+
+	Convey("Check record IDs may contain slashes ", t, func() {
+		So(serve("/dbs/TestRepository/groups/main/records/docs/guide/intro.json"), ShouldEqual, http.StatusOK)
+		So(readID, ShouldEqual, "record:docs/guide/intro.json")
+		So(serve("/dbs/TestRepository/groups/main/records/intro.json"), ShouldEqual, http.StatusOK)
+		So(readID, ShouldEqual, "record:intro.json")
+	})
+
+	Convey("Check the history of a record with slashes in its ID ", t, func() {
+		So(serve("/dbs/TestRepository/groups/main/records/docs/guide/intro.json/history"), ShouldEqual, http.StatusOK)
+		So(readID, ShouldEqual, "history:docs/guide/intro.json")
+	})
+
+	Convey("Check an escaped slash reaches a record whose ID ends in history ", t, func() {
+		So(serve("/dbs/TestRepository/groups/main/records/docs%2Fhistory"), ShouldEqual, http.StatusOK)
+		So(readID, ShouldEqual, "record:docs/history")
+		So(serve("/dbs/TestRepository/groups/main/records/history"), ShouldEqual, http.StatusOK)
+		So(readID, ShouldEqual, "record:history")
+	})
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"sync"
+	utils "xqledger/rdboperator/utils"
+)
+
+// classifyBatch splits a batch of jobs following utils.ClassifyEvents. Both sets keep
+// the arrival order of the batch.
+func classifyBatch(batch []job) (syncJobs []job, parJobs []job) {
+	events := make([]utils.RecordEvent, len(batch))
+	byKey := make(map[string][]job)
+	for i, j := range batch {
+		events[i] = j.event
+		key := eventKey(j.event)
+		byKey[key] = append(byKey[key], j)
+	}
+	// The sets keep the order of the batch, so the jobs of a key are taken in order
+	take := func(event utils.RecordEvent) job {
+		key := eventKey(event)
+		j := byKey[key][0]
+		byKey[key] = byKey[key][1:]
+		return j
+	}
+	set := utils.ClassifyEvents(events)
+	for _, event := range set.SyncEvents {
+		syncJobs = append(syncJobs, take(event))
+	}
+	for _, event := range set.ParEvents {
+		parJobs = append(parJobs, take(event))
+	}
+	return syncJobs, parJobs
+}
+
+// applyBatch processes a batch and returns once every job has been processed. The
+// serialised jobs are processed one after the other, in arrival order, while the
+// independent ones are processed concurrently, up to workers at a time, next to them.
+func applyBatch(batch []job, workers int, process func(job)) {
+	syncJobs, parJobs := classifyBatch(batch)
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, j := range syncJobs {
+			process(j)
+		}
+	}()
+	slots := make(chan struct{}, workers)
+	for _, j := range parJobs {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			process(j)
+		}(j)
+	}
+	wg.Wait()
+}
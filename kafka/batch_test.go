@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"sync"
+	"testing"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func batchJob(dbName string, recordID string, op string) job {
+	return job{event: utils.RecordEvent{Id: recordID, DBName: dbName, OperationType: op}}
+}
+
+func TestClassifyBatch(t *testing.T) {
+	Convey("Check jobs of the same DB are serialised and the rest run in parallel", t, func() {
+		syncJobs, parJobs := classifyBatch([]job{
+			batchJob(repo, "a", "new"),
+			batchJob("other", "b", "new"),
+			batchJob(repo, "d", "update"),
+			batchJob("third", "c", "new"),
+			batchJob(repo, "a", "delete"),
+		})
+		var syncOps []string
+		for _, j := range syncJobs {
+			syncOps = append(syncOps, j.event.Id+":"+j.event.OperationType)
+		}
+		So(syncOps, ShouldResemble, []string{"a:new", "d:update", "a:delete"})
+		So(len(parJobs), ShouldEqual, 2)
+		So(parJobs[0].event.Id, ShouldEqual, "b")
+		So(parJobs[1].event.Id, ShouldEqual, "c")
+	})
+}
+
+func TestApplyBatch(t *testing.T) {
+	Convey("Check every job is processed and serialised jobs keep their order", t, func() {
+		var mu sync.Mutex
+		var processed []string
+		batch := []job{batchJob(repo, "a", "new")}
+		for _, dbName := range []string{"b", "c", "d", "e", "f"} {
+			batch = append(batch, batchJob(dbName, dbName, "new"))
+		}
+		batch = append(batch, batchJob(repo, "a", "update"), batchJob(repo, "a", "delete"))
+		applyBatch(batch, 2, func(j job) {
+			mu.Lock()
+			defer mu.Unlock()
+			processed = append(processed, j.event.Id+":"+j.event.OperationType)
+		})
+		So(len(processed), ShouldEqual, len(batch))
+		var recordA []string
+		for _, op := range processed {
+			if op[0] == 'a' {
+				recordA = append(recordA, op)
+			}
+		}
+		So(recordA, ShouldResemble, []string{"a:new", "a:update", "a:delete"})
+	})
+}
@@ -1,21 +1,51 @@
 package kafka
 
 import (
-	"time"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	configuration "xqledger/rdboperator/configuration"
+	"xqledger/rdboperator/metrics"
+	rdb "xqledger/rdboperator/mongodb"
+	"xqledger/rdboperator/recordfeed"
 	utils "xqledger/rdboperator/utils"
+
 	kafka "github.com/segmentio/kafka-go"
-	rdb "xqledger/rdboperator/mongodb"
-	//pb "xqledger/rdboperator/protobuf"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const componentMessage = "Topics Consumer Service"
+const rdbComponentMessage = "MongoDB Client"
+
 var config = configuration.GlobalConfiguration
 
+// handleEvent applies an event to the RDB.
+var handleEvent = rdb.HandleEventContext
+
+// handleEvents applies a batch of events to the RDB.
+var handleEvents = rdb.HandleEventsContext
+
+// recordTransitions keeps the status transitions of events in their history.
+var recordTransitions = rdb.RecordTransitions
+
+// messageReader is the part of kafka.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Stats() kafka.ReaderStats
+	Close() error
+}
+
+// newReader opens the reader of the consumed topic.
+var newReader = func(topic string) messageReader {
+	return getKafkaReader(topic)
+}
 
 func getKafkaReader(topic string) *kafka.Reader {
 	broker := config.Kafka.Bootstrapserver
@@ -27,69 +57,449 @@ func getKafkaReader(topic string) *kafka.Reader {
 		Topic:    topic,
 		MinBytes: config.Kafka.Messageminsize,
 		MaxBytes: config.Kafka.Messagemaxsize,
-		MaxWait: 100 * time.Millisecond,
+		MaxWait:  100 * time.Millisecond,
 	})
 }
 
-// func StartListeningForStream(stream pb.RecordService_GetRDBRecordsStreamServer) {
-// 	methodMsg := "StartListeningForStream"
-// 	reader := getKafkaReader(config.Kafka.Gitactionbacktopic)
-// 	defer reader.Close()
-// 	for {
-// 		//m, err := reader.ReadMessage(context.Background())
-// 		m, err := reader.FetchMessage(context.Background()) // explicit commit
-// 		if err != nil {
-// 			utils.PrintLogError(err, componentMessage, methodMsg, fmt.Sprintf("Error reading message - Reason: %s", err.Error()))
-// 		}
-// 		msg := fmt.Sprintf("Message at topic:%v partition:%v offset:%v	%s = %s\n", m.Topic, m.Partition, m.Offset, string(m.Key), string(m.Value))
-// 		utils.PrintLogInfo(componentMessage, methodMsg, msg)
-// 		event, eventErr := convertMessageToProcessable(m)
-// 		if eventErr == nil {
-// 			utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Message converted to event successfully - Key '%s'", m.Key))
-// 			recordSet := pb.RecordSet{}
-// 			var records []string
-// 			records[0] = event.RecordContent
-// 			recordSet.Records = records
-// 			sendErr := stream.Send(&recordSet)
-// 			if sendErr != nil {
-// 				utils.PrintLogError(eventErr, componentMessage, methodMsg, fmt.Sprintf("Send output message to stream failed - Reason '%s'", sendErr.Error()))
-// 			}
-// 		}
-// 	}
-// }
-
-func StartListeningEvents(topic string) {
+// StartListeningEvents consumes the topic with at-least-once semantics: offsets are
+// committed only once the event has been applied to the RDB. Events are applied by a
+// pool of Kafka.Workers workers keyed by record, so the events of a record keep their
+// order, and favoured by RecordEvent.Priority, see priorityQueue.
+// With Kafka.Batchsize set, messages are gathered instead in batches, up to
+// Kafka.Messagemaxsize bytes or Kafka.Batchwait milliseconds, which are written with
+// bulk writes and whose offsets are committed at once. RecordSet messages are applied
+// alone, each one in a transaction, see rdb.HandleRecordSetContext. The events of a set
+// whose status is to be skipped are left out of its transaction, see processSet.
+// Events are applied, skipped or routed to Kafka.Reviewtopic as utils.StatusAction
+// decides. Every outcome is kept in the status history of the record and reported on
+// Kafka.Rdbinputtopic. Messages that cannot be converted or applied are routed to
+// Kafka.Deadlettertopic. Without a dead-letter topic, messages that cannot be
+// converted are committed and dropped, as they would never become valid. For events
+// that cannot be applied, or if the dead-letter topic cannot be written, the loop
+// stops without committing the message so that it is redelivered when the operator
+// restarts.
+// While running, the loop reports its progress to Ready. Every message gets a span,
+// child of the trace context in its headers, that lasts until it is applied.
+// When ctx is cancelled the loop stops fetching, waits up to Kafka.Shutdowntimeout
+// seconds for the events being applied and commits their offsets. Events that were
+// queued but not started are left for redelivery.
+func StartListeningEvents(parent context.Context, topic string) error {
 	methodMsg := "StartListeningEvents"
-	reader := getKafkaReader(topic)
+	reader := newReader(topic)
 	defer reader.Close()
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var deadLetterWriter *kafka.Writer
+	if len(config.Kafka.Deadlettertopic) > 0 {
+		deadLetterWriter = getKafkaWriter(config.Kafka.Deadlettertopic)
+		defer deadLetterWriter.Close()
+	}
+	var resultWriter *kafka.Writer
+	if len(config.Kafka.Rdbinputtopic) > 0 {
+		resultWriter = getResultWriter(config.Kafka.Rdbinputtopic)
+		defer resultWriter.Close()
+	}
+
+	var failOnce sync.Once
+	var failure error
+	// Events go to the worker pool one by one, or are applied in batches whose offsets
+	// are committed together
+	batching := config.Kafka.Batchsize > 0
+	newTracker := newOffsetTracker
+	if batching {
+		newTracker = newBatchOffsetTracker
+	}
+	tracker := newTracker(func(m kafka.Message) error {
+		commitErr := reader.CommitMessages(context.Background(), m)
+		if commitErr != nil {
+			utils.PrintLogErrorContext(messageContext(m), commitErr, componentMessage, methodMsg, fmt.Sprintf("%s - Offset %d of partition %d", utils.Event_commit_failed, m.Offset, m.Partition))
+		}
+		return commitErr
+	})
+	// Events routed for review go to their own topic, or with the failed ones
+	reviewWriter := deadLetterWriter
+	if len(config.Kafka.Reviewtopic) > 0 {
+		reviewWriter = getKafkaWriter(config.Kafka.Reviewtopic)
+		defer reviewWriter.Close()
+	}
+
+	// routeAside sends a message to the dead-letter or review topic and lets its offset
+	// be committed. If that is not possible the consumer is stopped.
+	routeAside := func(writer *kafka.Writer, m kafka.Message, rejection deadLetterFailure) {
+		if writer != nil && sendToDeadLetter(writer, m, rejection) == nil {
+			tracker.complete(m)
+			return
+		}
+		utils.PrintLogErrorContext(messageContext(m), rejection.reason, componentMessage, methodMsg, fmt.Sprintf("%s - Offset %d of partition %d not committed - Key '%s'", utils.Event_apply_failed, m.Offset, m.Partition, m.Key))
+		failOnce.Do(func() {
+			failure = rejection.reason
+			cancel()
+		})
+	}
+	reject := func(m kafka.Message, rejection deadLetterFailure) {
+		routeAside(deadLetterWriter, m, rejection)
+	}
+	// settle reports the outcome of an event once it has been handed to the RDB and its
+	// transition is in the history
+	settle := func(j job, attempts int, applyErr error) {
+		endSpan(trace.SpanFromContext(j.ctx), applyErr)
+		publishResult(resultWriter, j.ctx, j.event, attempts, applyErr)
+		if applyErr != nil {
+			reject(j.message, deadLetterFailure{applyErr, rdbComponentMessage, "HandleEvent", attempts})
+			return
+		}
+		recordfeed.Publish(j.event)
+		tracker.complete(j.message)
+	}
+	applied := func(j job, attempts int, applyErr error) {
+		recordTransition(j.ctx, j.event, utils.StatusActionApply, resultStatus(applyErr), errorReason(applyErr))
+		settle(j, attempts, applyErr)
+	}
+	process := func(j job) {
+		span := trace.SpanFromContext(j.ctx)
+		if ctx.Err() != nil {
+			// Shutting down or a previous event failed, leave the rest for redelivery
+			span.AddEvent("left for redelivery")
+			span.End()
+			return
+		}
+		switch action := utils.StatusAction(j.event.Status); action {
+		case utils.StatusActionSkip, utils.StatusActionReview:
+			statusErr := fmt.Errorf("%w: %s", ErrEventNotApplied, j.event.Status)
+			if action == utils.StatusActionReview {
+				statusErr = fmt.Errorf("%w: %s", ErrEventInReview, j.event.Status)
+			}
+			utils.PrintLogWarnContext(j.ctx, statusErr, componentMessage, methodMsg, fmt.Sprintf("Event not applied, action '%s' - ID '%s' - DB Name '%s'", action, j.event.Id, j.event.DBName))
+			endSpan(span, nil)
+			publishResult(resultWriter, j.ctx, j.event, 0, statusErr)
+			// The history is best effort, rdb.RecordTransitions logs every failure
+			recordTransition(j.ctx, j.event, action, resultStatus(statusErr), statusErr.Error())
+			if action == utils.StatusActionReview {
+				routeAside(reviewWriter, j.message, deadLetterFailure{statusErr, componentMessage, "StatusAction", 0})
+				return
+			}
+			tracker.complete(j.message)
+			return
+		}
+		attempts, applyErr := applyWithRetry(j.ctx, j.event)
+		if ctx.Err() != nil && errors.Is(applyErr, ctx.Err()) {
+			// Stopped while waiting to retry, leave it for redelivery
+			span.AddEvent("left for redelivery")
+			span.End()
+			return
+		}
+		applied(j, attempts, applyErr)
+	}
+	// processSet applies the events of a RecordSet message in one transaction. A set with
+	// any event in review is routed for review as a whole. Events whose status is to be
+	// skipped are not: they are left out of the transaction, reported as not applied,
+	// and the rest of the set is still applied and committed.
+	processSet := func(j job) {
+		span := trace.SpanFromContext(j.ctx)
+		if ctx.Err() != nil {
+			span.AddEvent("left for redelivery")
+			span.End()
+			return
+		}
+		var apply []utils.RecordEvent
+		review := false
+		for _, event := range j.set {
+			switch utils.StatusAction(event.Status) {
+			case utils.StatusActionApply:
+				apply = append(apply, event)
+			case utils.StatusActionReview:
+				review = true
+			}
+		}
+		if review {
+			statusErr := fmt.Errorf("%w: record set with events in review", ErrEventInReview)
+			utils.PrintLogWarnContext(j.ctx, statusErr, componentMessage, methodMsg, fmt.Sprintf("Record set not applied, action '%s' - %d events", utils.StatusActionReview, len(j.set)))
+			endSpan(span, nil)
+			for _, event := range j.set {
+				publishResult(resultWriter, j.ctx, event, 0, statusErr)
+				recordTransition(j.ctx, event, utils.StatusActionReview, resultStatus(statusErr), statusErr.Error())
+			}
+			routeAside(reviewWriter, j.message, deadLetterFailure{statusErr, componentMessage, "StatusAction", 0})
+			return
+		}
+		attempts, applyErr := applyRecordSetWithRetry(j.ctx, apply)
+		if ctx.Err() != nil && errors.Is(applyErr, ctx.Err()) {
+			span.AddEvent("left for redelivery")
+			span.End()
+			return
+		}
+		endSpan(span, applyErr)
+		for _, event := range j.set {
+			if action := utils.StatusAction(event.Status); action != utils.StatusActionApply {
+				statusErr := fmt.Errorf("%w: %s", ErrEventNotApplied, event.Status)
+				publishResult(resultWriter, j.ctx, event, 0, statusErr)
+				recordTransition(j.ctx, event, action, resultStatus(statusErr), statusErr.Error())
+				continue
+			}
+			publishResult(resultWriter, j.ctx, event, attempts, applyErr)
+			recordTransition(j.ctx, event, utils.StatusActionApply, resultStatus(applyErr), errorReason(applyErr))
+		}
+		if applyErr != nil {
+			reject(j.message, deadLetterFailure{applyErr, rdbComponentMessage, "HandleRecordSet", attempts})
+			return
+		}
+		for _, event := range apply {
+			recordfeed.Publish(event)
+		}
+		tracker.complete(j.message)
+	}
+	batchWait := time.Duration(config.Kafka.Batchwait) * time.Millisecond
+	var pool *workerPool
+	if !batching {
+		pool = newWorkerPool(config.Kafka.Workers, config.Kafka.Workerqueuesize, process)
+	}
+	var batch []job
+	var batchDeadline time.Time
+	var batchBytes int
+	// flush applies the events of the batch with bulk writes. Events that are not to be
+	// applied, and the ones whose bulk write failed transiently, was not attempted or
+	// could not check the preconditions of a patch, go through process, serialised or
+	// in parallel as applyBatch decides. The offsets completed since the previous flush
+	// are committed even without a batch, as rejected messages complete outside of it.
+	flush := func() {
+		defer tracker.commitPending()
+		if len(batch) == 0 {
+			return
+		}
+		var bulk, single []job
+		for _, j := range batch {
+			if ctx.Err() == nil && utils.StatusAction(j.event.Status) == utils.StatusActionApply {
+				bulk = append(bulk, j)
+			} else {
+				single = append(single, j)
+			}
+		}
+		if len(bulk) > 0 {
+			events := make([]rdb.BatchEvent, len(bulk))
+			for i, j := range bulk {
+				events[i] = rdb.BatchEvent{Ctx: j.ctx, Event: j.event}
+			}
+			// Not cancelled with ctx, so that a shutdown does not fail the batch being
+			// written, and attempted once, as transient failures go through process. The
+			// writes are traced from the contexts of the events.
+			results := handleEvents(rdb.WithoutRetry(context.WithoutCancel(ctx)), events)
+			var written []job
+			var writtenErrs []error
+			var transitions []rdb.Transition
+			for i, j := range bulk {
+				if errors.Is(results[i], rdb.ErrNotAttempted) || errors.Is(results[i], rdb.ErrPreconditionNotChecked) || rdb.IsTransientError(results[i]) {
+					single = append(single, j)
+					continue
+				}
+				written = append(written, j)
+				writtenErrs = append(writtenErrs, results[i])
+				transitions = append(transitions, rdb.Transition{Ctx: j.ctx, Event: j.event, Action: utils.StatusActionApply, Status: resultStatus(results[i]), Reason: errorReason(results[i])})
+			}
+			// The history of the written events is kept with one write per database
+			recordTransitions(context.WithoutCancel(ctx), transitions)
+			for i, j := range written {
+				settle(j, 1, writtenErrs[i])
+			}
+		}
+		applyBatch(single, config.Kafka.Workers, process)
+		batch = nil
+		batchBytes = 0
+	}
+
+	health.start()
+	defer health.stop()
+	heartbeat := time.Duration(config.Kafka.Heartbeatinterval) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 5 * time.Second
+	}
+	var lastObserved time.Time
 	for {
-		m, err := reader.ReadMessage(context.Background())
+		// Fetches are bounded by the heartbeat so that an idle loop still reports
+		// progress, and by the deadline of the batch being gathered
+		wait := heartbeat
+		if len(batch) > 0 && time.Until(batchDeadline) < wait {
+			wait = time.Until(batchDeadline)
+		}
+		fetchCtx, cancelFetch := context.WithTimeout(ctx, wait)
+		m, err := reader.FetchMessage(fetchCtx)
+		cancelFetch()
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				if batching && (len(batch) == 0 || !time.Now().Before(batchDeadline)) {
+					flush()
+				}
+				health.progress()
+				if time.Since(lastObserved) >= heartbeat {
+					observeReader(reader, topic)
+					lastObserved = time.Now()
+				}
+				continue
+			}
 			utils.PrintLogError(err, componentMessage, methodMsg, fmt.Sprintf("%s - Error reading message", utils.Event_topic_received_fail))
+			continue
 		}
+		correlationID := assignCorrelationID(&m)
+		logCtx := messageContext(m)
 		msg := fmt.Sprintf("Message at topic:%v partition:%v offset:%v	%s = %s\n", m.Topic, m.Partition, m.Offset, string(m.Key), string(m.Value))
-		utils.PrintLogInfo(componentMessage, methodMsg, msg)
-		event, eventErr := convertMessageToProcessable(m)
-		if eventErr != nil {
-			utils.PrintLogError(eventErr, componentMessage, methodMsg, fmt.Sprintf("%s - Message convertion error - Key '%s'", utils.Event_topic_received_unacceptable, m.Key))
+		utils.PrintLogInfoContext(logCtx, componentMessage, methodMsg, msg)
+		health.progress()
+		if time.Since(lastObserved) >= heartbeat {
+			observeReader(reader, topic)
+			lastObserved = time.Now()
+		}
+		metrics.MessagesConsumed.WithLabelValues(m.Topic, strconv.Itoa(m.Partition)).Inc()
+		tracker.track(m)
+		// Derived from ctx, so that a shutdown ends the wait between attempts
+		msgCtx, msgSpan := startMessageSpan(ctx, m)
+		msgSpan.SetAttributes(attribute.String("correlation.id", correlationID))
+		msgCtx = utils.WithCorrelationID(msgCtx, correlationID)
+		converter := "convertMessageToProcessable"
+		recordSet := isRecordSet(m.Value)
+		if recordSet {
+			converter = "convertMessageToRecordSet"
+		}
+		_, convertSpan := tracer.Start(msgCtx, converter)
+		var event utils.RecordEvent
+		var set utils.RecordSet
+		var eventErr error
+		if recordSet {
+			set, eventErr = convertMessageToRecordSet(m)
 		} else {
-			utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("%s - Message converted to event successfully - Key '%s'", utils.Event_topic_received_ok, m.Key))
-			go rdb.HandleEvent(event)
+			event, eventErr = convertMessageToProcessable(m)
+		}
+		endSpan(convertSpan, eventErr)
+		if eventErr != nil {
+			utils.PrintLogErrorContext(logCtx, eventErr, componentMessage, methodMsg, fmt.Sprintf("%s - Message convertion error - Key '%s'", utils.Event_topic_received_unacceptable, m.Key))
+			metrics.ConversionFailures.WithLabelValues(m.Topic).Inc()
+			publishResult(resultWriter, logCtx, event, 0, eventErr)
+			if deadLetterWriter == nil {
+				// Without a dead-letter topic the message is committed and dropped,
+				// redelivering it would not make the payload valid
+				tracker.complete(m)
+			} else {
+				reject(m, deadLetterFailure{eventErr, componentMessage, converter, 1})
+			}
+			endSpan(msgSpan, eventErr)
+			continue
+		}
+		utils.PrintLogInfoContext(logCtx, componentMessage, methodMsg, fmt.Sprintf("%s - Message converted to event successfully - Key '%s'", utils.Event_topic_received_ok, m.Key))
+		if recordSet {
+			// A record set is applied alone, once every event fetched before it is done,
+			// so that it keeps its order with the events of all its records
+			if batching {
+				flush()
+			} else {
+				pool.wait()
+			}
+			processSet(job{ctx: msgCtx, message: m, set: set.Records})
+			tracker.commitPending()
+			continue
+		}
+		j := job{ctx: msgCtx, message: m, event: event}
+		if !batching {
+			pool.submit(j)
+			continue
 		}
+		if len(batch) == 0 {
+			batchDeadline = time.Now().Add(batchWait)
+		}
+		batch = append(batch, j)
+		batchBytes += len(m.Value)
+		// The deadline is checked here too, as under steady traffic fetches keep
+		// returning messages
+		if len(batch) >= config.Kafka.Batchsize || (config.Kafka.Messagemaxsize > 0 && batchBytes >= config.Kafka.Messagemaxsize) || !time.Now().Before(batchDeadline) {
+			flush()
+		}
+	}
+	health.stop()
+	if batching {
+		// The batch being gathered is left for redelivery
+		flush()
+		return failure
 	}
+	utils.PrintLogInfo(componentMessage, methodMsg, "Stopped fetching messages - Waiting for in-flight events")
+	timeout := time.Duration(config.Kafka.Shutdowntimeout) * time.Second
+	if pool.closeWithin(timeout) {
+		utils.PrintLogInfo(componentMessage, methodMsg, "In-flight events finished - Offsets committed")
+	} else {
+		utils.PrintLogWarn(ctx.Err(), componentMessage, methodMsg, fmt.Sprintf("In-flight events not finished after %v - They will be redelivered", timeout))
+	}
+	return failure
+}
+
+// observeReader takes the reader stats once per heartbeat. Stats resets the reader
+// counters, so it is only read here and shared by readiness and metrics.
+// recordTransition keeps the status transition of an event in its history.
+func recordTransition(ctx context.Context, event utils.RecordEvent, action string, status string, reason string) {
+	recordTransitions(ctx, []rdb.Transition{{Ctx: ctx, Event: event, Action: action, Status: status, Reason: reason}})
+}
+
+func observeReader(reader messageReader, topic string) {
+	stats := reader.Stats()
+	health.observe(stats)
+	metrics.ReaderLag.WithLabelValues(topic).Set(float64(stats.Lag))
+}
+
+// applyWithRetry hands the event to the RDB, retrying transient failures up to
+// Rdb.Maxattempts times with exponential backoff from Rdb.Retrybasedelay up to
+// Rdb.Retrymaxdelay milliseconds, see rdb.BackoffDelay. Permanent failures are
+// returned straight away. It returns the number of attempts made.
+func applyWithRetry(ctx context.Context, event utils.RecordEvent) (int, error) {
+	return retryTransient(ctx, fmt.Sprintf("ID '%s' - DB Name '%s'", event.Id, event.DBName), func(ctx context.Context) error {
+		return handleEvent(ctx, event)
+	})
 }
 
+// retryTransient is the only retry of the events of the consumer: the RDB operations
+// of each attempt are made once, under rdb.WithoutRetry. An attempt that has started
+// is not cancelled with ctx, but the wait for the next one ends with ctx.Err().
+func retryTransient(ctx context.Context, subject string, apply func(ctx context.Context) error) (int, error) {
+	methodMsg := "applyWithRetry"
+	attempts := config.Rdb.Maxattempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	base := time.Duration(config.Rdb.Retrybasedelay) * time.Millisecond
+	max := time.Duration(config.Rdb.Retrymaxdelay) * time.Millisecond
+	applyCtx := rdb.WithoutRetry(context.WithoutCancel(ctx))
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = apply(applyCtx)
+		if err == nil {
+			return attempt, nil
+		}
+		if !rdb.IsTransientError(err) {
+			return attempt, err
+		}
+		utils.PrintLogWarnContext(ctx, err, componentMessage, methodMsg, fmt.Sprintf("Attempt %d of %d failed - %s", attempt, attempts, subject))
+		if attempt < attempts {
+			timer := time.NewTimer(rdb.BackoffDelay(attempt, base, max))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return attempt, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return attempts, err
+}
 
 func convertMessageToProcessable(msg kafka.Message) (utils.RecordEvent, error) {
 	methodMsg := "convertMessageToProcessable"
+	ctx := messageContext(msg)
 	var newRecordEvent utils.RecordEvent
 	unmarshalErr := json.Unmarshal(msg.Value, &newRecordEvent)
 	if unmarshalErr != nil {
-		utils.PrintLogWarn(unmarshalErr, componentMessage, methodMsg, fmt.Sprintf("Error unmarshaling message content to JSON - Key '%s'", msg.Key))
+		utils.PrintLogWarnContext(ctx, unmarshalErr, componentMessage, methodMsg, fmt.Sprintf("Error unmarshaling message content to JSON - Key '%s'", msg.Key))
 		return newRecordEvent, unmarshalErr
 	}
-	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("ID '%s'", newRecordEvent.Id))
-	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("DB Name '%s'", newRecordEvent.DBName))
-	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("OperationType '%s'", newRecordEvent.OperationType))
+	utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf("ID '%s'", newRecordEvent.Id))
+	utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf("DB Name '%s'", newRecordEvent.DBName))
+	utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf("OperationType '%s'", newRecordEvent.OperationType))
 	return newRecordEvent, nil
-}
\ No newline at end of file
+}
@@ -1,11 +1,17 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"sync"
 	"testing"
+	"time"
+	rdb "xqledger/rdboperator/mongodb"
 	utils "xqledger/rdboperator/utils"
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/mongo"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -99,4 +105,253 @@ func TestConvertMessageToProcessable(t *testing.T) {
 		So(event.OperationType, ShouldEqual, "delete")
 	})
 
-}
\ No newline at end of file
+}
+
+func TestApplyWithRetry(t *testing.T) {
+	defer func() { handleEvent = rdb.HandleEventContext }()
+	config.Rdb.Maxattempts = 3
+	config.Rdb.Retrybasedelay = 1
+	config.Rdb.Retrymaxdelay = 2
+
+	Convey("Check event applied after transient failures", t, func() {
+		calls := 0
+		handleEvent = func(ctx context.Context, event utils.RecordEvent) error {
+			calls++
+			if calls < 3 {
+				return mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+			}
+			return nil
+		}
+		attempts, err := applyWithRetry(context.Background(), utils.RecordEvent{Id: id, DBName: repo})
+		So(err, ShouldBeNil)
+		So(attempts, ShouldEqual, 3)
+		So(calls, ShouldEqual, 3)
+	})
+
+	Convey("Check error returned once attempts are exhausted", t, func() {
+		calls := 0
+		handleEvent = func(ctx context.Context, event utils.RecordEvent) error {
+			calls++
+			return mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+		}
+		attempts, err := applyWithRetry(context.Background(), utils.RecordEvent{Id: id, DBName: repo})
+		So(err, ShouldNotBeNil)
+		So(attempts, ShouldEqual, 3)
+		So(calls, ShouldEqual, 3)
+	})
+
+	Convey("Check a cancellation during the backoff ends the wait but not the attempt", t, func() {
+		config.Rdb.Retrybasedelay, config.Rdb.Retrymaxdelay = 60000, 60000
+		defer func() { config.Rdb.Retrybasedelay, config.Rdb.Retrymaxdelay = 1, 2 }()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		calls := 0
+		handleEvent = func(applyCtx context.Context, event utils.RecordEvent) error {
+			calls++
+			cancel()
+			So(applyCtx.Err(), ShouldBeNil)
+			return mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+		}
+		start := time.Now()
+		attempts, err := applyWithRetry(ctx, utils.RecordEvent{Id: id, DBName: repo})
+		So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		So(time.Since(start), ShouldBeLessThan, time.Second)
+		So(attempts, ShouldEqual, 1)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Check permanent errors are not retried", t, func() {
+		calls := 0
+		handleEvent = func(ctx context.Context, event utils.RecordEvent) error {
+			calls++
+			return errors.New("fake permanent error")
+		}
+		attempts, err := applyWithRetry(context.Background(), utils.RecordEvent{Id: id, DBName: repo})
+		So(err, ShouldNotBeNil)
+		So(attempts, ShouldEqual, 1)
+		So(calls, ShouldEqual, 1)
+	})
+}
+
+// fakeReader hands out the messages of next and, once there are none, waits for the
+// fetch to time out or be cancelled.
+type fakeReader struct {
+	mu        sync.Mutex
+	next      func() (kafka.Message, bool)
+	committed []int64
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	m, ok := r.next()
+	r.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range msgs {
+		r.committed = append(r.committed, m.Offset)
+	}
+	return nil
+}
+
+func (r *fakeReader) Stats() kafka.ReaderStats { return kafka.ReaderStats{} }
+
+func (r *fakeReader) Close() error { return nil }
+
+func (r *fakeReader) commits() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.committed...)
+}
+
+func queuedMessages(messages ...kafka.Message) func() (kafka.Message, bool) {
+	return func() (kafka.Message, bool) {
+		if len(messages) == 0 {
+			return kafka.Message{}, false
+		}
+		m := messages[0]
+		messages = messages[1:]
+		return m, true
+	}
+}
+
+func eventMessage(offset int64, recordID string) kafka.Message {
+	value, _ := json.Marshal(utils.RecordEvent{Id: recordID, DBName: repo, OperationType: "new", RecordContent: `{"name":"test"}`})
+	return kafka.Message{Topic: "gitoperator-out", Offset: offset, Key: []byte(recordID), Value: value}
+}
+
+// listen runs the consumer loop on reader, without any output topic nor history, until
+// the returned stop is called.
+func listen(reader *fakeReader) (stop func() error) {
+	kafkaConfig := config.Kafka
+	newReader = func(topic string) messageReader { return reader }
+	recordTransitions = func(ctx context.Context, transitions []rdb.Transition) error { return nil }
+	config.Kafka.Deadlettertopic, config.Kafka.Rdbinputtopic, config.Kafka.Reviewtopic = "", "", ""
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- StartListeningEvents(ctx, "gitoperator-out") }()
+	return func() error {
+		cancel()
+		err := <-done
+		config.Kafka = kafkaConfig
+		newReader = func(topic string) messageReader { return getKafkaReader(topic) }
+		recordTransitions = rdb.RecordTransitions
+		return err
+	}
+}
+
+func waitFor(condition func() bool) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStartListeningEventsBatches(t *testing.T) {
+	defer func() { handleEvent, handleEvents = rdb.HandleEventContext, rdb.HandleEventsContext }()
+
+	Convey("Check batches are written in bulk, transient failures applied one by one and offsets committed per batch", t, func() {
+		config.Kafka.Batchsize, config.Kafka.Batchwait = 3, 60000
+		defer func() { config.Kafka.Batchsize, config.Kafka.Batchwait = 0, 200 }()
+		var mu sync.Mutex
+		var batches [][]string
+		var single []string
+		handleEvents = func(ctx context.Context, batch []rdb.BatchEvent) []error {
+			mu.Lock()
+			defer mu.Unlock()
+			var ids []string
+			results := make([]error, len(batch))
+			for i, b := range batch {
+				ids = append(ids, b.Event.Id)
+				if b.Event.Id == "b" {
+					results[i] = mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+				}
+			}
+			batches = append(batches, ids)
+			return results
+		}
+		handleEvent = func(ctx context.Context, event utils.RecordEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			single = append(single, event.Id)
+			return nil
+		}
+		reader := &fakeReader{next: queuedMessages(
+			eventMessage(0, "a"), eventMessage(1, "b"), eventMessage(2, "c"),
+			eventMessage(3, "d"), eventMessage(4, "e"), eventMessage(5, "f"),
+		)}
+		stop := listen(reader)
+		So(waitFor(func() bool { return len(reader.commits()) == 2 }), ShouldBeTrue)
+		So(stop(), ShouldBeNil)
+		So(batches, ShouldResemble, [][]string{{"a", "b", "c"}, {"d", "e", "f"}})
+		So(single, ShouldResemble, []string{"b"})
+		So(reader.commits(), ShouldResemble, []int64{2, 5})
+	})
+
+	Convey("Check a batch is written once its time is up under steady traffic", t, func() {
+		config.Kafka.Batchsize, config.Kafka.Batchwait = 1000, 20
+		defer func() { config.Kafka.Batchsize, config.Kafka.Batchwait = 0, 200 }()
+		const traffic = 300
+		var mu sync.Mutex
+		produced := 0
+		var flushedAt []int
+		handleEvents = func(ctx context.Context, batch []rdb.BatchEvent) []error {
+			mu.Lock()
+			defer mu.Unlock()
+			flushedAt = append(flushedAt, produced)
+			return make([]error, len(batch))
+		}
+		reader := &fakeReader{next: func() (kafka.Message, bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			if produced == traffic {
+				return kafka.Message{}, false
+			}
+			time.Sleep(time.Millisecond)
+			produced++
+			return eventMessage(int64(produced-1), uuid.NewString()), true
+		}}
+		stop := listen(reader)
+		So(waitFor(func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return produced == traffic
+		}), ShouldBeTrue)
+		So(stop(), ShouldBeNil)
+		So(len(flushedAt), ShouldBeGreaterThan, 1)
+		So(flushedAt[0], ShouldBeLessThan, traffic)
+	})
+}
+
+func TestStartListeningEventsUnconvertible(t *testing.T) {
+	defer func() { handleEvent = rdb.HandleEventContext }()
+
+	Convey("Check a message that cannot be converted is committed and dropped without a dead-letter topic", t, func() {
+		var mu sync.Mutex
+		var applied []string
+		handleEvent = func(ctx context.Context, event utils.RecordEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			applied = append(applied, event.Id)
+			return nil
+		}
+		reader := &fakeReader{next: queuedMessages(
+			kafka.Message{Topic: "gitoperator-out", Offset: 0, Value: []byte("{")},
+			eventMessage(1, "a"),
+		)}
+		stop := listen(reader)
+		So(waitFor(func() bool { return len(reader.commits()) == 2 }), ShouldBeTrue)
+		So(stop(), ShouldBeNil)
+		So(applied, ShouldResemble, []string{"a"})
+		So(reader.commits(), ShouldResemble, []int64{0, 1})
+	})
+}
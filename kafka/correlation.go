@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	utils "xqledger/rdboperator/utils"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// headerCorrelationID is the header carrying the correlation ID of an event
+const headerCorrelationID = "correlation-id"
+
+func headerValue(m kafka.Message, key string) string {
+	for _, header := range m.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// assignCorrelationID returns the correlation ID set by the producer. Messages
+// without one get an ID derived from their position only, so a redelivered
+// message keeps its ID, which is added to their headers so it also reaches the
+// dead-letter topic.
+func assignCorrelationID(m *kafka.Message) string {
+	if correlationID := headerValue(*m, headerCorrelationID); len(correlationID) > 0 {
+		return correlationID
+	}
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s/%d/%d", m.Topic, m.Partition, m.Offset)))
+	correlationID := hex.EncodeToString(hash[:])
+	m.Headers = append(m.Headers, kafka.Header{Key: headerCorrelationID, Value: []byte(correlationID)})
+	return correlationID
+}
+
+// messageContext returns a context carrying the correlation ID of a message, for the
+// log lines written about it.
+func messageContext(m kafka.Message) context.Context {
+	return utils.WithCorrelationID(context.Background(), headerValue(m, headerCorrelationID))
+}
@@ -0,0 +1,36 @@
+package kafka
+
+import (
+	"testing"
+	utils "xqledger/rdboperator/utils"
+
+	kafka "github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAssignCorrelationID(t *testing.T) {
+	Convey("Check the correlation ID of the producer is kept ", t, func() {
+		m := kafka.Message{Headers: []kafka.Header{{Key: headerCorrelationID, Value: []byte("abc")}}}
+		So(assignCorrelationID(&m), ShouldEqual, "abc")
+		So(len(m.Headers), ShouldEqual, 1)
+		So(utils.CorrelationIDFrom(messageContext(m)), ShouldEqual, "abc")
+	})
+
+	Convey("Check messages without correlation ID get one in their headers ", t, func() {
+		m := kafka.Message{Topic: "gitoperator-out", Partition: 1, Offset: 42, Key: []byte("key")}
+		correlationID := assignCorrelationID(&m)
+		So(len(correlationID), ShouldEqual, 40)
+		So(headerValue(m, headerCorrelationID), ShouldEqual, correlationID)
+		So(utils.CorrelationIDFrom(messageContext(m)), ShouldEqual, correlationID)
+	})
+
+	Convey("Check a redelivered message gets the same correlation ID ", t, func() {
+		first := kafka.Message{Topic: "gitoperator-out", Partition: 1, Offset: 42, Key: []byte("key")}
+		redelivered := first
+		So(assignCorrelationID(&redelivered), ShouldEqual, assignCorrelationID(&first))
+		other := kafka.Message{Topic: "gitoperator-out", Partition: 1, Offset: 43, Key: []byte("key")}
+		So(assignCorrelationID(&other), ShouldNotEqual, assignCorrelationID(&first))
+		rekeyed := kafka.Message{Topic: "gitoperator-out", Partition: 1, Offset: 42, Key: []byte("other")}
+		So(assignCorrelationID(&rekeyed), ShouldEqual, assignCorrelationID(&first))
+	})
+}
@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	utils "xqledger/rdboperator/utils"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Headers added to the original message when it is routed to the dead-letter topic
+const (
+	headerDeadLetterReason          = "dlq-reason"
+	headerDeadLetterComponent       = "dlq-component"
+	headerDeadLetterPhase           = "dlq-phase"
+	headerDeadLetterAttempts        = "dlq-attempts"
+	headerDeadLetterSourceTopic     = "dlq-source-topic"
+	headerDeadLetterSourcePartition = "dlq-source-partition"
+	headerDeadLetterSourceOffset    = "dlq-source-offset"
+	headerDeadLetterTime            = "dlq-time"
+)
+
+// deadLetterFailure describes why a message could not be processed.
+type deadLetterFailure struct {
+	reason    error
+	component string
+	phase     string
+	attempts  int
+}
+
+func getKafkaWriter(topic string) *kafka.Writer {
+	brokers := strings.Split(config.Kafka.Bootstrapserver, ",")
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+}
+
+// deadLetterMessage keeps the original key, value and headers so the message can be
+// replayed as is, and appends the failure details as headers.
+func deadLetterMessage(m kafka.Message, failure deadLetterFailure) kafka.Message {
+	headers := make([]kafka.Header, 0, len(m.Headers)+8)
+	headers = append(headers, m.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: headerDeadLetterReason, Value: []byte(failure.reason.Error())},
+		kafka.Header{Key: headerDeadLetterComponent, Value: []byte(failure.component)},
+		kafka.Header{Key: headerDeadLetterPhase, Value: []byte(failure.phase)},
+		kafka.Header{Key: headerDeadLetterAttempts, Value: []byte(strconv.Itoa(failure.attempts))},
+		kafka.Header{Key: headerDeadLetterSourceTopic, Value: []byte(m.Topic)},
+		kafka.Header{Key: headerDeadLetterSourcePartition, Value: []byte(strconv.Itoa(m.Partition))},
+		kafka.Header{Key: headerDeadLetterSourceOffset, Value: []byte(strconv.FormatInt(m.Offset, 10))},
+		kafka.Header{Key: headerDeadLetterTime, Value: []byte(strconv.FormatInt(utils.GetEpochNow(), 10))},
+	)
+	return kafka.Message{
+		Key:     m.Key,
+		Value:   m.Value,
+		Headers: headers,
+	}
+}
+
+func sendToDeadLetter(writer *kafka.Writer, m kafka.Message, failure deadLetterFailure) error {
+	methodMsg := "sendToDeadLetter"
+	err := writer.WriteMessages(context.Background(), deadLetterMessage(m, failure))
+	if err != nil {
+		utils.PrintLogErrorContext(messageContext(m), err, componentMessage, methodMsg, fmt.Sprintf("%s - Topic '%s' - Offset %d of partition %d", utils.Event_dead_letter_failed, writer.Topic, m.Offset, m.Partition))
+		return err
+	}
+	utils.PrintLogInfoContext(messageContext(m), componentMessage, methodMsg, fmt.Sprintf("%s - Topic '%s' - Offset %d of partition %d - Reason '%s'", utils.Event_dead_letter_sent, writer.Topic, m.Offset, m.Partition, failure.reason.Error()))
+	return nil
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	kafka "github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func getHeader(m kafka.Message, key string) string {
+	for _, h := range m.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestDeadLetterMessage(t *testing.T) {
+	Convey("Check dead-letter message keeps the original message and adds failure headers", t, func() {
+		original := kafka.Message{
+			Topic:     "gitoperator-out",
+			Partition: 2,
+			Offset:    42,
+			Key:       []byte("key"),
+			Value:     getEvent(),
+			Headers:   []kafka.Header{{Key: "traceparent", Value: []byte("00-abc-def-01")}},
+		}
+		failure := deadLetterFailure{errors.New("duplicate key"), rdbComponentMessage, "HandleEvent", 3}
+		result := deadLetterMessage(original, failure)
+		So(string(result.Key), ShouldEqual, "key")
+		So(result.Value, ShouldResemble, original.Value)
+		So(result.Topic, ShouldBeEmpty)
+		So(getHeader(result, "traceparent"), ShouldEqual, "00-abc-def-01")
+		So(getHeader(result, headerDeadLetterReason), ShouldEqual, "duplicate key")
+		So(getHeader(result, headerDeadLetterComponent), ShouldEqual, rdbComponentMessage)
+		So(getHeader(result, headerDeadLetterPhase), ShouldEqual, "HandleEvent")
+		So(getHeader(result, headerDeadLetterAttempts), ShouldEqual, "3")
+		So(getHeader(result, headerDeadLetterSourceTopic), ShouldEqual, "gitoperator-out")
+		So(getHeader(result, headerDeadLetterSourcePartition), ShouldEqual, "2")
+		So(getHeader(result, headerDeadLetterSourceOffset), ShouldEqual, "42")
+	})
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// ErrConsumerNotRunning is reported by Ready while the consumer loop is not running
+var ErrConsumerNotRunning = errors.New("consumer not running")
+
+// ErrGroupNotJoined is reported by Ready until the reader joins its consumer group
+var ErrGroupNotJoined = errors.New("consumer group not joined")
+
+// ErrConsumerStalled is reported by Ready when the consumer loop has not made
+// progress for Kafka.Progresstimeout seconds
+var ErrConsumerStalled = errors.New("consumer not making progress")
+
+// consumerHealth is the state of the consumer loop as seen by the readiness probe.
+type consumerHealth struct {
+	mu           sync.Mutex
+	running      bool
+	joined       bool
+	lastProgress time.Time
+}
+
+var health = &consumerHealth{}
+
+func (h *consumerHealth) start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.running = true
+	h.joined = false
+	h.lastProgress = time.Now()
+}
+
+func (h *consumerHealth) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.running = false
+}
+
+// progress records that the loop is alive: it either fetched a message or waited a
+// whole heartbeat without one.
+func (h *consumerHealth) progress() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastProgress = time.Now()
+}
+
+// observe checks the reader stats for a rebalance, which is when the reader joins its
+// group and gets its partitions.
+func (h *consumerHealth) observe(stats kafka.ReaderStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if stats.Rebalances > 0 {
+		h.joined = true
+	}
+}
+
+func (h *consumerHealth) check(now time.Time, progressTimeout time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case !h.running:
+		return ErrConsumerNotRunning
+	case !h.joined:
+		return fmt.Errorf("%w: %s", ErrGroupNotJoined, config.Kafka.Groupid)
+	case now.Sub(h.lastProgress) > progressTimeout:
+		return fmt.Errorf("%w: last progress %v ago", ErrConsumerStalled, now.Sub(h.lastProgress).Round(time.Second))
+	}
+	return nil
+}
+
+// Ready reports whether the consumer loop is running, has joined its consumer group
+// and has made progress in the last Kafka.Progresstimeout seconds.
+func Ready() error {
+	return health.check(time.Now(), time.Duration(config.Kafka.Progresstimeout)*time.Second)
+}
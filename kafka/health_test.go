@@ -0,0 +1,30 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConsumerHealth(t *testing.T) {
+	Convey("Check readiness follows the consumer loop ", t, func() {
+		h := &consumerHealth{}
+		now := time.Now()
+		So(errors.Is(h.check(now, time.Minute), ErrConsumerNotRunning), ShouldBeTrue)
+
+		h.start()
+		So(errors.Is(h.check(now, time.Minute), ErrGroupNotJoined), ShouldBeTrue)
+
+		h.observe(kafka.ReaderStats{})
+		So(errors.Is(h.check(now, time.Minute), ErrGroupNotJoined), ShouldBeTrue)
+		h.observe(kafka.ReaderStats{Rebalances: 1})
+		So(h.check(time.Now(), time.Minute), ShouldBeNil)
+		So(errors.Is(h.check(time.Now().Add(2*time.Minute), time.Minute), ErrConsumerStalled), ShouldBeTrue)
+
+		h.stop()
+		So(errors.Is(h.check(time.Now(), time.Minute), ErrConsumerNotRunning), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"sync"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+type partitionOffsets struct {
+	inFlight []int64 // fetched offsets not yet committed, in fetch order
+	done     map[int64]kafka.Message
+}
+
+// offsetTracker commits offsets in order when events complete out of order. An
+// offset is committed only when it and every offset fetched before it in the same
+// partition have completed.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+	commit     func(kafka.Message) error
+	deferred   bool                             // commits wait for commitPending
+	pending    map[topicPartition]kafka.Message // highest committable offset per partition
+}
+
+func newOffsetTracker(commit func(kafka.Message) error) *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[topicPartition]*partitionOffsets),
+		commit:     commit,
+	}
+}
+
+// newBatchOffsetTracker is like newOffsetTracker, but complete only moves the offsets
+// forward: they are committed by commitPending, once per batch.
+func newBatchOffsetTracker(commit func(kafka.Message) error) *offsetTracker {
+	t := newOffsetTracker(commit)
+	t.deferred = true
+	t.pending = make(map[topicPartition]kafka.Message)
+	return t
+}
+
+// track registers a fetched message. It must be called in fetch order.
+func (t *offsetTracker) track(m kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := topicPartition{m.Topic, m.Partition}
+	p, ok := t.partitions[key]
+	if !ok || (len(p.inFlight) > 0 && m.Offset <= p.inFlight[len(p.inFlight)-1]) {
+		// First message of the partition, or redelivery after a rebalance
+		p = &partitionOffsets{done: make(map[int64]kafka.Message)}
+		t.partitions[key] = p
+	}
+	p.inFlight = append(p.inFlight, m.Offset)
+}
+
+// complete marks a message as finished and commits the highest contiguous offset
+// of its partition, if it moved forward.
+func (t *offsetTracker) complete(m kafka.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[topicPartition{m.Topic, m.Partition}]
+	if !ok {
+		return nil
+	}
+	p.done[m.Offset] = m
+	var last *kafka.Message
+	for len(p.inFlight) > 0 {
+		head, finished := p.done[p.inFlight[0]]
+		if !finished {
+			break
+		}
+		delete(p.done, head.Offset)
+		p.inFlight = p.inFlight[1:]
+		last = &head
+	}
+	if last == nil {
+		return nil
+	}
+	if t.deferred {
+		t.pending[topicPartition{m.Topic, m.Partition}] = *last
+		return nil
+	}
+	return t.commit(*last)
+}
+
+// commitPending commits the offsets moved forward since the previous call, one commit
+// per partition. It returns the first commit error.
+func (t *offsetTracker) commitPending() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var err error
+	for key, m := range t.pending {
+		if commitErr := t.commit(m); commitErr != nil && err == nil {
+			err = commitErr
+		}
+		delete(t.pending, key)
+	}
+	return err
+}
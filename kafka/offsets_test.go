@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"testing"
+
+	kafka "github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOffsetTracker(t *testing.T) {
+	Convey("Check offsets are committed only when all previous offsets completed", t, func() {
+		var committed []int64
+		tracker := newOffsetTracker(func(m kafka.Message) error {
+			committed = append(committed, m.Offset)
+			return nil
+		})
+		messages := []kafka.Message{}
+		for offset := int64(10); offset < 14; offset++ {
+			m := kafka.Message{Topic: "gitoperator-out", Partition: 0, Offset: offset}
+			messages = append(messages, m)
+			tracker.track(m)
+		}
+		tracker.complete(messages[1])
+		tracker.complete(messages[3])
+		So(committed, ShouldBeEmpty)
+		tracker.complete(messages[0])
+		So(committed, ShouldResemble, []int64{11})
+		tracker.complete(messages[2])
+		So(committed, ShouldResemble, []int64{11, 13})
+	})
+
+	Convey("Check partitions are tracked independently", t, func() {
+		var committed []int64
+		tracker := newOffsetTracker(func(m kafka.Message) error {
+			committed = append(committed, m.Offset)
+			return nil
+		})
+		first := kafka.Message{Topic: "gitoperator-out", Partition: 0, Offset: 5}
+		second := kafka.Message{Topic: "gitoperator-out", Partition: 1, Offset: 7}
+		tracker.track(first)
+		tracker.track(second)
+		tracker.complete(second)
+		So(committed, ShouldResemble, []int64{7})
+	})
+	Convey("Check a batch tracker commits once per batch", t, func() {
+		var committed []int64
+		tracker := newBatchOffsetTracker(func(m kafka.Message) error {
+			committed = append(committed, m.Offset)
+			return nil
+		})
+		for offset := int64(20); offset < 23; offset++ {
+			m := kafka.Message{Topic: "gitoperator-out", Partition: 0, Offset: offset}
+			tracker.track(m)
+			tracker.complete(m)
+		}
+		So(committed, ShouldBeEmpty)
+		So(tracker.commitPending(), ShouldBeNil)
+		So(committed, ShouldResemble, []int64{22})
+		So(tracker.commitPending(), ShouldBeNil)
+		So(committed, ShouldResemble, []int64{22})
+	})
+}
@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Priority levels of a RecordEvent, in dequeue preference order
+const (
+	priorityHigh = iota
+	priorityMedium
+	priorityLow
+	priorityLevels
+)
+
+// priorityLevel maps RecordEvent.Priority to a level. Events without a valid
+// priority are MEDIUM.
+func priorityLevel(priority string) int {
+	switch strings.ToUpper(strings.TrimSpace(priority)) {
+	case "HIGH":
+		return priorityHigh
+	case "LOW":
+		return priorityLow
+	default:
+		return priorityMedium
+	}
+}
+
+type queuedJob struct {
+	job      job
+	key      string
+	enqueued time.Time
+}
+
+// pendingKey tracks the events of a record waiting in a priorityQueue. While a
+// record has pending events they are all in the same level.
+type pendingKey struct {
+	level int
+	count int
+}
+
+// priorityQueue is the queue of a worker: a FIFO per priority level, sharing a
+// capacity, dequeued by smooth weighted round robin so every level gets a share of
+// the worker in proportion to its weight. The head of a level that has waited longer
+// than maxWait is served first, so low priorities cannot starve even with a weight of
+// zero.
+// A new event of a record that still has events queued joins the level where they
+// are, whatever its own priority, so the events of a record never overtake each other.
+type priorityQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	levels   [priorityLevels][]queuedJob
+	pending  map[string]*pendingKey
+	weights  [priorityLevels]int
+	credits  [priorityLevels]int
+	maxWait  time.Duration
+	size     int
+	capacity int
+	closed   bool
+}
+
+func newPriorityQueue(capacity int, weights [priorityLevels]int, maxWait time.Duration) *priorityQueue {
+	if capacity < 1 {
+		capacity = 1
+	}
+	q := &priorityQueue{
+		pending:  make(map[string]*pendingKey),
+		weights:  weights,
+		maxWait:  maxWait,
+		capacity: capacity,
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// push blocks while the queue is full. It reports false if the queue was closed.
+func (q *priorityQueue) push(j job, key string, level int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size >= q.capacity && !q.closed {
+		q.notFull.Wait()
+	}
+	if q.closed {
+		return false
+	}
+	if p, found := q.pending[key]; found {
+		level = p.level
+		p.count++
+	} else {
+		q.pending[key] = &pendingKey{level: level, count: 1}
+	}
+	q.levels[level] = append(q.levels[level], queuedJob{job: j, key: key, enqueued: time.Now()})
+	q.size++
+	q.notEmpty.Signal()
+	return true
+}
+
+// pop blocks until there is a job. Once the queue is closed the remaining jobs are
+// still returned, and then pop reports false.
+func (q *priorityQueue) pop() (job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	if q.size == 0 {
+		return job{}, false
+	}
+	level := q.next(time.Now())
+	queued := q.levels[level][0]
+	q.levels[level][0] = queuedJob{}
+	q.levels[level] = q.levels[level][1:]
+	q.size--
+	if p := q.pending[queued.key]; p.count == 1 {
+		delete(q.pending, queued.key)
+	} else {
+		p.count--
+	}
+	q.notFull.Signal()
+	return queued.job, true
+}
+
+// next picks the level to dequeue from. It must be called with the lock held and a
+// non-empty queue.
+func (q *priorityQueue) next(now time.Time) int {
+	// Starvation protection: the oldest head past maxWait goes first
+	aged := -1
+	for level := range q.levels {
+		if len(q.levels[level]) == 0 || q.maxWait <= 0 || now.Sub(q.levels[level][0].enqueued) < q.maxWait {
+			continue
+		}
+		if aged < 0 || q.levels[level][0].enqueued.Before(q.levels[aged][0].enqueued) {
+			aged = level
+		}
+	}
+	if aged >= 0 {
+		return aged
+	}
+	// Smooth weighted round robin among the levels with jobs
+	best, total := -1, 0
+	for level := range q.levels {
+		if len(q.levels[level]) == 0 {
+			continue
+		}
+		weight := q.weights[level]
+		if weight < 0 {
+			weight = 0
+		}
+		q.credits[level] += weight
+		total += weight
+		if best < 0 || q.credits[level] > q.credits[best] {
+			best = level
+		}
+	}
+	q.credits[best] -= total
+	return best
+}
+
+// close wakes up every waiting push and pop.
+func (q *priorityQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func priorityJob(recordID string, priority string, op string) job {
+	return job{event: utils.RecordEvent{Id: recordID, DBName: repo, Priority: priority, OperationType: op}}
+}
+
+func pushJob(q *priorityQueue, j job) {
+	q.push(j, eventKey(j.event), priorityLevel(j.event.Priority))
+}
+
+func drain(q *priorityQueue) []job {
+	q.close()
+	var jobs []job
+	for {
+		j, ok := q.pop()
+		if !ok {
+			return jobs
+		}
+		jobs = append(jobs, j)
+	}
+}
+
+func TestPriorityLevel(t *testing.T) {
+	Convey("Check priorities are mapped to levels", t, func() {
+		So(priorityLevel("HIGH"), ShouldEqual, priorityHigh)
+		So(priorityLevel("low"), ShouldEqual, priorityLow)
+		So(priorityLevel("MEDIUM"), ShouldEqual, priorityMedium)
+		So(priorityLevel(""), ShouldEqual, priorityMedium)
+		So(priorityLevel("URGENT"), ShouldEqual, priorityMedium)
+	})
+}
+
+func TestPriorityQueueWeights(t *testing.T) {
+	Convey("Check levels are dequeued in proportion to their weights", t, func() {
+		q := newPriorityQueue(100, [priorityLevels]int{3, 2, 1}, 0)
+		for i := 0; i < 12; i++ {
+			for _, priority := range []string{"LOW", "MEDIUM", "HIGH"} {
+				pushJob(q, priorityJob(priority+string(rune('a'+i)), priority, "new"))
+			}
+		}
+		counts := make(map[string]int)
+		for i := 0; i < 12; i++ {
+			j, ok := q.pop()
+			So(ok, ShouldBeTrue)
+			counts[j.event.Priority]++
+		}
+		So(counts["HIGH"], ShouldEqual, 6)
+		So(counts["MEDIUM"], ShouldEqual, 4)
+		So(counts["LOW"], ShouldEqual, 2)
+		So(len(drain(q)), ShouldEqual, 24)
+	})
+}
+
+func TestPriorityQueueStarvation(t *testing.T) {
+	Convey("Check a job waiting longer than maxWait is served first", t, func() {
+		q := newPriorityQueue(100, [priorityLevels]int{1, 0, 0}, 20*time.Millisecond)
+		pushJob(q, priorityJob("import", "LOW", "new"))
+		time.Sleep(30 * time.Millisecond)
+		pushJob(q, priorityJob("user", "HIGH", "new"))
+		j, _ := q.pop()
+		So(j.event.Id, ShouldEqual, "import")
+	})
+
+	Convey("Check a level without weight still drains", t, func() {
+		q := newPriorityQueue(100, [priorityLevels]int{1, 0, 0}, 0)
+		pushJob(q, priorityJob("import", "LOW", "new"))
+		So(len(drain(q)), ShouldEqual, 1)
+	})
+}
+
+func TestPriorityQueueKeepsRecordOrder(t *testing.T) {
+	Convey("Check a high priority event does not overtake queued events of its record", t, func() {
+		q := newPriorityQueue(100, [priorityLevels]int{100, 10, 1}, 0)
+		pushJob(q, priorityJob("a", "LOW", "new"))
+		pushJob(q, priorityJob("b", "MEDIUM", "new"))
+		pushJob(q, priorityJob("a", "HIGH", "update"))
+		pushJob(q, priorityJob("c", "HIGH", "new"))
+		var order []string
+		for _, j := range drain(q) {
+			order = append(order, j.event.Id+":"+j.event.OperationType)
+		}
+		So(order, ShouldResemble, []string{"c:new", "b:new", "a:new", "a:update"})
+	})
+
+	Convey("Check a record gets its own priority again once its events are applied", t, func() {
+		q := newPriorityQueue(100, [priorityLevels]int{100, 10, 1}, 0)
+		pushJob(q, priorityJob("a", "LOW", "new"))
+		q.pop()
+		pushJob(q, priorityJob("b", "MEDIUM", "new"))
+		pushJob(q, priorityJob("a", "HIGH", "update"))
+		j, _ := q.pop()
+		So(j.event.Id, ShouldEqual, "a")
+	})
+}
+
+func TestPriorityQueueCapacity(t *testing.T) {
+	Convey("Check push blocks while the queue is full", t, func() {
+		q := newPriorityQueue(1, [priorityLevels]int{1, 1, 1}, 0)
+		pushJob(q, priorityJob("a", "LOW", "new"))
+		pushed := make(chan struct{})
+		go func() {
+			pushJob(q, priorityJob("b", "HIGH", "new"))
+			close(pushed)
+		}()
+		select {
+		case <-pushed:
+			t.Fatal("push did not block on a full queue")
+		case <-time.After(20 * time.Millisecond):
+		}
+		q.pop()
+		<-pushed
+		So(len(drain(q)), ShouldEqual, 1)
+	})
+}
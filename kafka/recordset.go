@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// handleRecordSet applies the events of a record set to the RDB as a whole.
+var handleRecordSet = rdb.HandleRecordSetContext
+
+// isRecordSet tells a RecordSet payload, a JSON object with a recordset field, from a
+// single RecordEvent.
+func isRecordSet(value []byte) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(value, &fields) != nil {
+		return false
+	}
+	_, found := fields["recordset"]
+	return found
+}
+
+func convertMessageToRecordSet(msg kafka.Message) (utils.RecordSet, error) {
+	methodMsg := "convertMessageToRecordSet"
+	ctx := messageContext(msg)
+	var recordSet utils.RecordSet
+	unmarshalErr := json.Unmarshal(msg.Value, &recordSet)
+	if unmarshalErr != nil {
+		utils.PrintLogWarnContext(ctx, unmarshalErr, componentMessage, methodMsg, fmt.Sprintf("Error unmarshaling message content to JSON - Key '%s'", msg.Key))
+		return recordSet, unmarshalErr
+	}
+	for _, event := range recordSet.Records {
+		utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf("ID '%s' - DB Name '%s' - OperationType '%s'", event.Id, event.DBName, event.OperationType))
+	}
+	return recordSet, nil
+}
+
+// applyRecordSetWithRetry is applyWithRetry for the events of a record set, which are
+// retried as a whole.
+func applyRecordSetWithRetry(ctx context.Context, events []utils.RecordEvent) (int, error) {
+	return retryTransient(ctx, fmt.Sprintf("Record set of %d events", len(events)), func(ctx context.Context) error {
+		return handleRecordSet(ctx, events)
+	})
+}
@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+
+	"github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func getRecordSet() []byte {
+	recordSet := utils.RecordSet{Records: []utils.RecordEvent{
+		{Id: id, DBName: repo, OperationType: "new", RecordContent: `{"name":"first"}`},
+		{Id: "223456789123456789123456", DBName: repo, OperationType: "update", RecordContent: `{"name":"second"}`},
+	}}
+	value, _ := json.Marshal(recordSet)
+	return value
+}
+
+func TestIsRecordSet(t *testing.T) {
+	Convey("Check record sets are told from single events", t, func() {
+		So(isRecordSet(getRecordSet()), ShouldBeTrue)
+		So(isRecordSet(getEvent()), ShouldBeFalse)
+		So(isRecordSet([]byte("not json")), ShouldBeFalse)
+	})
+}
+
+func TestConvertMessageToRecordSet(t *testing.T) {
+	Convey("Check the events of a record set keep their order", t, func() {
+		recordSet, err := convertMessageToRecordSet(kafka.Message{Topic: "gitoperator-out", Value: getRecordSet()})
+		So(err, ShouldBeNil)
+		So(len(recordSet.Records), ShouldEqual, 2)
+		So(recordSet.Records[0].OperationType, ShouldEqual, "new")
+		So(recordSet.Records[1].OperationType, ShouldEqual, "update")
+	})
+
+	Convey("Check a malformed record set is reported", t, func() {
+		_, err := convertMessageToRecordSet(kafka.Message{Topic: "gitoperator-out", Value: []byte(`{"recordset": {}}`)})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestApplyRecordSetWithRetry(t *testing.T) {
+	defer func() { handleRecordSet = rdb.HandleRecordSetContext }()
+	config.Rdb.Maxattempts = 3
+	config.Rdb.Retrybasedelay = 1
+	config.Rdb.Retrymaxdelay = 2
+
+	Convey("Check a record set is retried as a whole after transient failures", t, func() {
+		calls := 0
+		handleRecordSet = func(ctx context.Context, events []utils.RecordEvent) error {
+			calls++
+			So(len(events), ShouldEqual, 2)
+			if calls < 2 {
+				return mongo.CommandError{Code: 112, Name: "WriteConflict"}
+			}
+			return nil
+		}
+		recordSet, _ := convertMessageToRecordSet(kafka.Message{Value: getRecordSet()})
+		attempts, err := applyRecordSetWithRetry(context.Background(), recordSet.Records)
+		So(err, ShouldBeNil)
+		So(attempts, ShouldEqual, 2)
+	})
+
+	Convey("Check a record set is not retried on a server without transactions", t, func() {
+		calls := 0
+		handleRecordSet = func(ctx context.Context, events []utils.RecordEvent) error {
+			calls++
+			return rdb.ErrTransactionsNotSupported
+		}
+		recordSet, _ := convertMessageToRecordSet(kafka.Message{Value: getRecordSet()})
+		attempts, err := applyRecordSetWithRetry(context.Background(), recordSet.Records)
+		So(err, ShouldEqual, rdb.ErrTransactionsNotSupported)
+		So(attempts, ShouldEqual, 1)
+		So(calls, ShouldEqual, 1)
+	})
+}
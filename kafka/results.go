@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// ErrEventNotApplied is the reason given for events skipped because of their status
+var ErrEventNotApplied = errors.New("event not applied because of its status")
+
+// ErrEventInReview is the reason given for events routed for review because of their
+// status
+var ErrEventInReview = errors.New("event routed for review because of its status")
+
+// getResultWriter returns an asynchronous writer for the result events. Results are
+// notifications: a slow or failing result topic must not hold back the commits, so
+// delivery failures are only logged.
+func getResultWriter(topic string) *kafka.Writer {
+	writer := getKafkaWriter(topic)
+	writer.Async = true
+	writer.Completion = func(messages []kafka.Message, err error) {
+		if err == nil {
+			return
+		}
+		for _, m := range messages {
+			utils.PrintLogErrorContext(messageContext(m), err, componentMessage, "publishResult", fmt.Sprintf("%s - Topic '%s' - Key '%s'", utils.Event_result_failed, topic, m.Key))
+		}
+	}
+	return writer
+}
+
+// resultStatus turns the outcome of an event into its final status. Events that can
+// never be applied as they are, because of their content or the state of the
+// record, are NOTVALID; any other failure is INCOMPLETE.
+func resultStatus(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return utils.StatusComplete
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr),
+		errors.Is(err, rdb.ErrMissingID), errors.Is(err, rdb.ErrInvalidID), errors.Is(err, rdb.ErrRecordNotFound),
+		errors.Is(err, rdb.ErrInvalidPatch), errors.Is(err, rdb.ErrPatchTestFailed), errors.Is(err, rdb.ErrUnsupportedOperation),
+		errors.Is(err, ErrEventNotApplied):
+		return utils.StatusNotValid
+	default:
+		return utils.StatusIncomplete
+	}
+}
+
+func resultEvent(ctx context.Context, event utils.RecordEvent, attempts int, err error) utils.RecordResultEvent {
+	result := utils.RecordResultEvent{
+		Id:             event.Id,
+		Group:          event.Group,
+		DBName:         event.DBName,
+		User:           event.User,
+		OperationType:  event.OperationType,
+		Status:         resultStatus(err),
+		Attempts:       attempts,
+		SendingTime:    event.SendingTime,
+		ReceptionTime:  event.ReceptionTime,
+		ProcessingTime: event.ProcessingTime,
+		ResultTime:     utils.GetEpochNow(),
+		CorrelationID:  utils.CorrelationIDFrom(ctx),
+	}
+	result.Reason = errorReason(err)
+	return result
+}
+
+func errorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// resultMessage keys the result like the record, so the results of a record keep
+// their order.
+func resultMessage(result utils.RecordResultEvent) (kafka.Message, error) {
+	value, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return kafka.Message{}, marshalErr
+	}
+	return kafka.Message{
+		Key:   []byte(strings.Join([]string{result.DBName, result.Group, result.Id}, "/")),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: headerCorrelationID, Value: []byte(result.CorrelationID)},
+		},
+	}, nil
+}
+
+// publishResult reports the outcome of an event on the result topic, if there is one.
+func publishResult(writer *kafka.Writer, ctx context.Context, event utils.RecordEvent, attempts int, err error) {
+	if writer == nil {
+		return
+	}
+	methodMsg := "publishResult"
+	message, marshalErr := resultMessage(resultEvent(ctx, event, attempts, err))
+	if marshalErr != nil {
+		utils.PrintLogErrorContext(ctx, marshalErr, componentMessage, methodMsg, utils.Event_result_failed)
+		return
+	}
+	writeErr := writer.WriteMessages(context.Background(), message)
+	if writeErr != nil {
+		utils.PrintLogErrorContext(ctx, writeErr, componentMessage, methodMsg, utils.Event_result_failed)
+	}
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestResultStatus(t *testing.T) {
+	Convey("Check the final status of an event ", t, func() {
+		So(resultStatus(nil), ShouldEqual, utils.StatusComplete)
+		So(resultStatus(json.Unmarshal([]byte("{"), &utils.RecordEvent{})), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(rdb.ErrMissingID), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(fmt.Errorf("%w '1': bad hex", rdb.ErrInvalidID)), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(rdb.ErrRecordNotFound), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(fmt.Errorf("%w: bad path", rdb.ErrInvalidPatch)), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(rdb.ErrPatchTestFailed), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(fmt.Errorf("%w: 'rename'", rdb.ErrUnsupportedOperation)), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(mongo.CommandError{Code: 189}), ShouldEqual, utils.StatusIncomplete)
+		So(resultStatus(errors.New("boom")), ShouldEqual, utils.StatusIncomplete)
+		So(resultStatus(fmt.Errorf("%w: NOTVALID", ErrEventNotApplied)), ShouldEqual, utils.StatusNotValid)
+		So(resultStatus(fmt.Errorf("%w: INCOMPLETE", ErrEventInReview)), ShouldEqual, utils.StatusIncomplete)
+	})
+}
+
+func TestResultMessage(t *testing.T) {
+	Convey("Check the result keeps the original event and the outcome ", t, func() {
+		ctx := utils.WithCorrelationID(context.Background(), "abc")
+		event := utils.RecordEvent{Id: id, Group: "main", DBName: repo, User: email, OperationType: "update", SendingTime: recordTime, ProcessingTime: recordTime}
+		message, err := resultMessage(resultEvent(ctx, event, 3, rdb.ErrRecordNotFound))
+		So(err, ShouldBeNil)
+		So(string(message.Key), ShouldEqual, repo+"/main/"+id)
+		So(headerValue(message, headerCorrelationID), ShouldEqual, "abc")
+
+		var result utils.RecordResultEvent
+		So(json.Unmarshal(message.Value, &result), ShouldBeNil)
+		So(result.Id, ShouldEqual, id)
+		So(result.DBName, ShouldEqual, repo)
+		So(result.Group, ShouldEqual, "main")
+		So(result.Status, ShouldEqual, utils.StatusNotValid)
+		So(result.Reason, ShouldEqual, rdb.ErrRecordNotFound.Error())
+		So(result.Attempts, ShouldEqual, 3)
+		So(result.SendingTime, ShouldEqual, recordTime)
+		So(result.ResultTime, ShouldBeGreaterThan, 0)
+		So(result.CorrelationID, ShouldEqual, "abc")
+	})
+
+	Convey("Check results are not published without a result topic ", t, func() {
+		So(func() { publishResult(nil, context.Background(), utils.RecordEvent{}, 1, nil) }, ShouldNotPanic)
+	})
+}
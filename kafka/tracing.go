@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"xqledger/rdboperator/tracing"
+
+	kafka "github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("kafka")
+
+// headerCarrier adapts the headers of a Kafka message to the OpenTelemetry
+// propagators, so the trace context set by the producer is carried over.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	for i, header := range *c.headers {
+		if header.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
+
+// startMessageSpan starts the consumer span of a message as a child of the trace
+// context found in its headers, in a context derived from ctx. The span lasts until
+// the event has been applied or rejected.
+func startMessageSpan(ctx context.Context, m kafka.Message) (context.Context, trace.Span) {
+	parent := otel.GetTextMapPropagator().Extract(ctx, headerCarrier{&m.Headers})
+	return tracer.Start(parent, m.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", m.Topic),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("messaging.kafka.message_key", string(m.Key)),
+			attribute.String("messaging.kafka.partition", strconv.Itoa(m.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", m.Offset),
+		))
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kafka "github.com/segmentio/kafka-go"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHeaderCarrier(t *testing.T) {
+	Convey("Check headers are read, replaced and added ", t, func() {
+		headers := []kafka.Header{{Key: "traceparent", Value: []byte("old")}}
+		carrier := headerCarrier{&headers}
+		carrier.Set("traceparent", "new")
+		carrier.Set("tracestate", "state")
+		So(carrier.Get("traceparent"), ShouldEqual, "new")
+		So(carrier.Get("tracestate"), ShouldEqual, "state")
+		So(carrier.Get("missing"), ShouldEqual, "")
+		So(carrier.Keys(), ShouldResemble, []string{"traceparent", "tracestate"})
+	})
+}
+
+func TestStartMessageSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	Convey("Check the span of a message continues the trace of its producer ", t, func() {
+		exporter.Reset()
+		producerCtx, producerSpan := provider.Tracer("producer").Start(context.Background(), "send")
+		var headers []kafka.Header
+		otel.GetTextMapPropagator().Inject(producerCtx, headerCarrier{&headers})
+		producerSpan.End()
+
+		_, span := startMessageSpan(context.Background(), kafka.Message{Topic: "gitoperator-out", Partition: 2, Offset: 7, Headers: headers})
+		endSpan(span, errors.New("boom"))
+
+		spans := exporter.GetSpans()
+		So(len(spans), ShouldEqual, 2)
+		consumer := spans[1]
+		So(consumer.Name, ShouldEqual, "gitoperator-out process")
+		So(consumer.Parent.TraceID(), ShouldEqual, producerSpan.SpanContext().TraceID())
+		So(consumer.Parent.SpanID(), ShouldEqual, producerSpan.SpanContext().SpanID())
+		So(consumer.Status.Code, ShouldEqual, codes.Error)
+	})
+
+	Convey("Check a message without trace context starts a new trace ", t, func() {
+		exporter.Reset()
+		_, span := startMessageSpan(context.Background(), kafka.Message{Topic: "gitoperator-out"})
+		endSpan(span, nil)
+		spans := exporter.GetSpans()
+		So(len(spans), ShouldEqual, 1)
+		So(spans[0].Parent.IsValid(), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+	"xqledger/rdboperator/metrics"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// job is a converted event together with the message it came from, so that the
+// offset can be committed once the event has been applied, and the context carrying
+// the span of the message. The events of a RecordSet message are in set instead.
+type job struct {
+	ctx     context.Context
+	message kafka.Message
+	event   utils.RecordEvent
+	set     []utils.RecordEvent
+}
+
+// workerPool runs a fixed number of workers, each one with its own priority queue.
+// Events are routed by record key so all the events of a record are applied in arrival
+// order, while events of unrelated records are applied in parallel.
+type workerPool struct {
+	queues  []*priorityQueue
+	wg      sync.WaitGroup
+	pending sync.WaitGroup // jobs submitted and not yet processed
+}
+
+func newWorkerPool(size int, queueSize int, process func(job)) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	weights := priorityWeights()
+	maxWait := time.Duration(config.Kafka.Prioritymaxwait) * time.Millisecond
+	pool := &workerPool{queues: make([]*priorityQueue, size)}
+	for i := range pool.queues {
+		queue := newPriorityQueue(queueSize, weights, maxWait)
+		pool.queues[i] = queue
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			for {
+				j, ok := queue.pop()
+				if !ok {
+					return
+				}
+				metrics.WorkerQueueDepth.Dec()
+				process(j)
+				pool.pending.Done()
+			}
+		}()
+	}
+	return pool
+}
+
+// priorityWeights reads the share of each priority level. When no weight is
+// configured every level gets the same share.
+func priorityWeights() [priorityLevels]int {
+	weights := [priorityLevels]int{
+		priorityHigh:   config.Kafka.Highpriorityweight,
+		priorityMedium: config.Kafka.Mediumpriorityweight,
+		priorityLow:    config.Kafka.Lowpriorityweight,
+	}
+	for _, weight := range weights {
+		if weight > 0 {
+			return weights
+		}
+	}
+	return [priorityLevels]int{1, 1, 1}
+}
+
+// submit blocks while the queue of the target worker is full, which throttles fetching.
+func (p *workerPool) submit(j job) {
+	key := eventKey(j.event)
+	metrics.WorkerQueueDepth.Inc()
+	p.pending.Add(1)
+	if !p.queues[workerIndex(key, len(p.queues))].push(j, key, priorityLevel(j.event.Priority)) {
+		metrics.WorkerQueueDepth.Dec()
+		p.pending.Done()
+	}
+}
+
+// wait blocks until every submitted job has been processed.
+func (p *workerPool) wait() {
+	p.pending.Wait()
+}
+
+// close stops accepting jobs and waits until every queued job has been processed.
+func (p *workerPool) close() {
+	for _, queue := range p.queues {
+		queue.close()
+	}
+	p.wg.Wait()
+}
+
+// closeWithin is like close but gives up waiting after timeout. It reports whether
+// all the workers finished in time.
+func (p *workerPool) closeWithin(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		p.close()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// eventKey identifies the record of an event by the database and collection it is
+// written to, which several DB names and groups can share.
+func eventKey(event utils.RecordEvent) string {
+	dbName, colName := rdb.RecordLocation(event.DBName, event.Group)
+	return dbName + "/" + colName + "/" + event.Id
+}
+
+func workerIndex(key string, size int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(size))
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"sync"
+	"testing"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWorkerIndex(t *testing.T) {
+	Convey("Check the same record is always routed to the same worker", t, func() {
+		key := eventKey(utils.RecordEvent{Id: id, DBName: repo, Group: "main"})
+		So(workerIndex(key, 8), ShouldEqual, workerIndex(key, 8))
+		So(workerIndex(key, 8), ShouldBeBetweenOrEqual, 0, 7)
+	})
+
+	Convey("Check names of the same database and collection give the same key", t, func() {
+		So(eventKey(utils.RecordEvent{Id: id, DBName: "Test.Repo"}), ShouldEqual, eventKey(utils.RecordEvent{Id: id, DBName: "TestRepo", Group: "main"}))
+		So(eventKey(utils.RecordEvent{Id: id, DBName: repo, Group: "Users"}), ShouldNotEqual, eventKey(utils.RecordEvent{Id: id, DBName: repo}))
+	})
+}
+
+func TestWorkerPoolKeepsRecordOrder(t *testing.T) {
+	Convey("Check events of a record are processed in arrival order", t, func() {
+		var mu sync.Mutex
+		processed := make(map[string][]string)
+		pool := newWorkerPool(4, 2, func(j job) {
+			mu.Lock()
+			defer mu.Unlock()
+			processed[j.event.Id] = append(processed[j.event.Id], j.event.OperationType)
+		})
+		for _, recordID := range []string{"a", "b", "c", "d", "e"} {
+			for _, op := range []string{"new", "update", "delete"} {
+				pool.submit(job{event: utils.RecordEvent{Id: recordID, DBName: repo, OperationType: op}})
+			}
+		}
+		pool.close()
+		So(len(processed), ShouldEqual, 5)
+		for _, ops := range processed {
+			So(ops, ShouldResemble, []string{"new", "update", "delete"})
+		}
+	})
+}
+
+func TestWorkerPoolCloseWithin(t *testing.T) {
+	Convey("Check close gives up waiting for a stuck worker", t, func() {
+		release := make(chan struct{})
+		defer close(release)
+		pool := newWorkerPool(1, 1, func(j job) {
+			<-release
+		})
+		pool.submit(job{event: utils.RecordEvent{Id: id, DBName: repo}})
+		So(pool.closeWithin(10*time.Millisecond), ShouldBeFalse)
+	})
+
+	Convey("Check close reports workers finished in time", t, func() {
+		pool := newWorkerPool(2, 1, func(j job) {})
+		pool.submit(job{event: utils.RecordEvent{Id: id, DBName: repo}})
+		So(pool.closeWithin(time.Second), ShouldBeTrue)
+	})
+}
+
+func TestWorkerPoolWait(t *testing.T) {
+	Convey("Check wait returns once every submitted job is processed", t, func() {
+		var mu sync.Mutex
+		processed := 0
+		pool := newWorkerPool(2, 4, func(j job) {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			processed++
+		})
+		defer pool.close()
+		for _, recordID := range []string{"a", "b", "c", "d"} {
+			pool.submit(job{event: utils.RecordEvent{Id: recordID, DBName: repo}})
+		}
+		pool.wait()
+		mu.Lock()
+		defer mu.Unlock()
+		So(processed, ShouldEqual, 4)
+	})
+}
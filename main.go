@@ -1,8 +1,17 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	configuration "xqledger/rdboperator/configuration"
+	"xqledger/rdboperator/grpcserver"
+	"xqledger/rdboperator/httpserver"
 	"xqledger/rdboperator/kafka"
+	rdb "xqledger/rdboperator/mongodb"
+	"xqledger/rdboperator/tracing"
 	utils "xqledger/rdboperator/utils"
 )
 
@@ -11,6 +20,57 @@ const componentMessage = "Main process"
 func main() {
 	config := configuration.GlobalConfiguration
 
+	ctx, stop := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		utils.PrintLogInfo("RDB Operator", componentMessage, "Signal received, shutting down: "+sig.String())
+		stop()
+	}()
+
+	shutdownTracing, tracingErr := tracing.Init(ctx)
+	if tracingErr != nil {
+		utils.PrintLogError(tracingErr, "RDB Operator", componentMessage, "Tracing not started")
+		os.Exit(1)
+	}
+
+	connectErr := rdb.Connect()
+	if connectErr != nil {
+		utils.PrintLogError(connectErr, "RDB Operator", componentMessage, "RDB not reachable on startup")
+		os.Exit(1)
+	}
+
+	if config.Grpc.Enabled {
+		serverErr := grpcserver.Start()
+		if serverErr != nil {
+			utils.PrintLogError(serverErr, "RDB Operator", componentMessage, "gRPC server not started")
+			rdb.Close()
+			os.Exit(1)
+		}
+	}
+
+	if config.Http.Enabled {
+		serverErr := httpserver.Start()
+		if serverErr != nil {
+			utils.PrintLogError(serverErr, "RDB Operator", componentMessage, "HTTP server not started")
+			grpcserver.Stop()
+			rdb.Close()
+			os.Exit(1)
+		}
+	}
+
 	utils.PrintLogInfo("RDB Operator", componentMessage, "Start listening topic with incoming successful writing events")
-	kafka.StartListeningEvents(config.Kafka.Gitactionbacktopic)
+	err := kafka.StartListeningEvents(ctx, config.Kafka.Gitactionbacktopic)
+	httpserver.Stop()
+	grpcserver.Stop()
+	rdb.Close()
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownTracing(flushCtx)
+	cancelFlush()
+	if err != nil {
+		utils.PrintLogError(err, "RDB Operator", componentMessage, "Stopped listening topic - Pending events will be redelivered on restart")
+		os.Exit(1)
+	}
+	utils.PrintLogInfo("RDB Operator", componentMessage, "Shutdown complete")
 }
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "rdboperator"
+
+// Outcomes of an RDB operation
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+var (
+	// MessagesConsumed counts the messages fetched from Kafka
+	MessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_consumed_total",
+		Help:      "Messages fetched from Kafka.",
+	}, []string{"topic", "partition"})
+
+	// ConversionFailures counts the messages that could not be converted to events
+	ConversionFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "conversion_failures_total",
+		Help:      "Messages that could not be converted to record events.",
+	}, []string{"topic"})
+
+	// RDBOperations counts the events applied to the RDB by operation type, outcome
+	// and database
+	RDBOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rdb_operations_total",
+		Help:      "Record events applied to the RDB.",
+	}, []string{"operation", "outcome", "dbname"})
+
+	// RDBOperationDuration measures every single call to MongoDB, retries included
+	RDBOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rdb_operation_duration_seconds",
+		Help:      "Latency of the MongoDB operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"operation"})
+
+	// EventLatency measures the time spent by the applied events in each stage of the
+	// pipeline, from the client to the RDB, by database
+	EventLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_latency_seconds",
+		Help:      "Latency of the record events by pipeline stage: client_to_api, api_to_gitoperator, gitoperator_to_rdb and total.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 14),
+	}, []string{"stage", "dbname"})
+
+	// WorkerQueueDepth is the number of events waiting in the worker queues
+	WorkerQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_queue_depth",
+		Help:      "Events waiting in the worker queues.",
+	})
+
+	// ReaderLag is the lag of the Kafka reader as reported by its stats
+	ReaderLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "kafka_reader_lag",
+		Help:      "Messages behind the end of the topic, from the Kafka reader stats.",
+	}, []string{"topic"})
+)
+
+// Outcome returns the outcome label of an operation that ended with err.
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
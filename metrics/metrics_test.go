@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOutcome(t *testing.T) {
+	Convey("Check the outcome label of an operation ", t, func() {
+		So(Outcome(nil), ShouldEqual, OutcomeSuccess)
+		So(Outcome(errors.New("boom")), ShouldEqual, OutcomeFailure)
+	})
+}
+
+func TestRDBOperations(t *testing.T) {
+	Convey("Check operations are counted by type, outcome and database ", t, func() {
+		RDBOperations.WithLabelValues("new", OutcomeSuccess, "TestRepository").Inc()
+		RDBOperations.WithLabelValues("new", OutcomeFailure, "TestRepository").Inc()
+		So(testutil.ToFloat64(RDBOperations.WithLabelValues("new", OutcomeSuccess, "TestRepository")), ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,327 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// operationFailures holds the log message of a failed write of each operation type
+var operationFailures = map[string]string{
+	"new":          utils.Error_inserting_record_in_RDB,
+	"update":       utils.Error_updating_record_in_RDB,
+	"delete":       utils.Error_deletion_record_in_RDB,
+	OperationMerge: utils.Error_updating_record_in_RDB,
+	OperationPatch: utils.Error_updating_record_in_RDB,
+}
+
+// ErrNotAttempted is returned for the events of a batch that were not written because
+// an earlier event of the same record failed. They are to be applied one by one.
+var ErrNotAttempted = errors.New("event not attempted, an earlier event of the record failed")
+
+// ErrPreconditionNotChecked is returned for the patches of a batch that have
+// conditions: a bulk write does not tell a record that fails them from a missing one,
+// so they are to be applied one by one.
+var ErrPreconditionNotChecked = errors.New("patch preconditions not checked in a bulk write")
+
+// BatchEvent is an event of a batch with its own context, which carries the span and
+// the correlation ID of the event.
+type BatchEvent struct {
+	Ctx   context.Context
+	Event utils.RecordEvent
+}
+
+// bulkOperation is an event of a batch turned into a write model.
+type bulkOperation struct {
+	index     int
+	ctx       context.Context
+	event     utils.RecordEvent
+	key       string
+	target    recordTarget
+	document  map[string]interface{}
+	patch     recordPatch
+	err       error // the event could not be turned into a write model
+	appliedAt time.Time
+}
+
+// HandleEventsContext applies a batch of events with bulk writes and returns the
+// outcome of each event, in batch order. The events are written in rounds, the n-th
+// round holding the n-th event of each record, so the events of a record keep their
+// order while every round is one unordered BulkWrite per database and collection.
+// Once an event of a record fails, the following ones fail with ErrNotAttempted, and
+// patches with conditions fail with ErrPreconditionNotChecked.
+// The write modes have the same meaning as in HandleEventContext: each round reads
+// which of its records exist beforehand, as the counts of a bulk write are not kept
+// per model, so the operator must be the only writer of its records.
+func HandleEventsContext(ctx context.Context, batch []BatchEvent) []error {
+	methodMsg := "HandleEvents"
+	results := make([]error, len(batch))
+	rdbClient, err := getRDBClient()
+	if err != nil {
+		utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_unmarshalling_RDB)
+		for i := range results {
+			results[i] = err
+		}
+		return results
+	}
+	var rounds [][]*bulkOperation
+	occurrences := make(map[string]int)
+	for i, b := range batch {
+		op := newBulkOperation(i, b)
+		round := occurrences[op.key]
+		occurrences[op.key]++
+		if round == len(rounds) {
+			rounds = append(rounds, nil)
+		}
+		rounds[round] = append(rounds[round], op)
+	}
+	failed := make(map[string]bool)
+	for _, round := range rounds {
+		var groups [][]*bulkOperation
+		groupIndex := make(map[string]int)
+		for _, op := range round {
+			switch {
+			case failed[op.key]:
+				results[op.index] = ErrNotAttempted
+			case op.err != nil:
+				results[op.index] = op.err
+				failed[op.key] = true
+			default:
+				group := op.target.dbName + "/" + op.target.colName
+				if _, found := groupIndex[group]; !found {
+					groupIndex[group] = len(groups)
+					groups = append(groups, nil)
+				}
+				groups[groupIndex[group]] = append(groups[groupIndex[group]], op)
+			}
+		}
+		for _, group := range groups {
+			for i, opErr := range bulkWrite(ctx, rdbClient, group) {
+				results[group[i].index] = opErr
+				if opErr != nil {
+					failed[group[i].key] = true
+				}
+			}
+		}
+	}
+	return results
+}
+
+func newBulkOperation(index int, b BatchEvent) *bulkOperation {
+	methodMsg := "HandleEvents"
+	op := &bulkOperation{
+		index:     index,
+		ctx:       b.Ctx,
+		event:     b.Event,
+		key:       databaseName(b.Event.DBName) + "/" + collectionName(b.Event.Group) + "/" + b.Event.Id,
+		appliedAt: time.Now(),
+	}
+	if _, supported := operationFailures[op.event.OperationType]; !supported {
+		op.err = fmt.Errorf("%w: '%s'", ErrUnsupportedOperation, op.event.OperationType)
+		utils.PrintLogErrorContext(op.ctx, op.err, componentMessage, methodMsg, "Error applying event to RDB")
+		return op
+	}
+	switch op.event.OperationType {
+	case "delete":
+	case OperationMerge, OperationPatch:
+		patch, patchErr := parsePatch(op.event.OperationType, op.event.RecordContent)
+		if patchErr != nil {
+			utils.PrintLogErrorContext(op.ctx, patchErr, componentMessage, methodMsg, "Error turning patch into an update")
+			op.err = patchErr
+			return op
+		}
+		if len(patch.conditions) > 0 {
+			op.err = ErrPreconditionNotChecked
+			return op
+		}
+		patch.setField(metadataField, eventMetadata(op.event, utils.CorrelationIDFrom(op.ctx), op.appliedAt))
+		op.patch = patch
+	default:
+		op.document = make(map[string]interface{})
+		if mapErr := json.Unmarshal([]byte(op.event.RecordContent), &op.document); mapErr != nil {
+			utils.PrintLogErrorContext(op.ctx, mapErr, componentMessage, methodMsg, "Error unmarshaling record to map")
+			op.err = mapErr
+			return op
+		}
+		op.document[metadataField] = eventMetadata(op.event, utils.CorrelationIDFrom(op.ctx), op.appliedAt)
+	}
+	target, targetErr := resolveTarget(op.event.DBName, op.event.Group, op.event.Id)
+	if targetErr != nil {
+		utils.PrintLogErrorContext(op.ctx, targetErr, componentMessage, methodMsg, "Error resolving record location in RDB")
+		op.err = targetErr
+		return op
+	}
+	op.target = target
+	if op.document != nil {
+		op.document["_id"] = target.id
+	}
+	return op
+}
+
+// bulkWrite writes the operations of a round that share database and collection, and
+// returns the outcome of each one. The write is traced as part of the first event.
+func bulkWrite(ctx context.Context, client *mongo.Client, ops []*bulkOperation) []error {
+	methodMsg := "bulkWrite"
+	ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(ops[0].ctx))
+	results := make([]error, len(ops))
+	target := ops[0].target
+	col := target.collection(client)
+	existing, findErr := existingRecords(ctx, col, ops)
+	if findErr != nil {
+		utils.PrintLogErrorContext(ctx, findErr, componentMessage, methodMsg, fmt.Sprintf("Error reading records - Database '%s' - Collection '%s'", target.dbName, target.colName))
+		for i := range results {
+			results[i] = findErr
+		}
+		return results
+	}
+	var models []mongo.WriteModel
+	var modelOps []int
+	written := make([]string, len(ops))
+	for i, op := range ops {
+		model, message, modelErr := writeModel(op, existing[idText(op.target.id)])
+		results[i] = modelErr
+		if model != nil {
+			models = append(models, model)
+			modelOps = append(modelOps, i)
+			written[i] = message
+		}
+	}
+	if len(models) > 0 {
+		bulkErr := withRetry(ctx, methodMsg, timed(ctx, "bulk", target, func(ctx context.Context) error {
+			_, writeErr := col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+			return writeErr
+		}))
+		var bulkException mongo.BulkWriteException
+		if errors.As(bulkErr, &bulkException) && bulkException.WriteConcernError == nil && len(bulkException.WriteErrors) > 0 {
+			for _, writeErr := range bulkException.WriteErrors {
+				i := modelOps[writeErr.Index]
+				opErr := mongo.WriteException{WriteErrors: mongo.WriteErrors{writeErr.WriteError}, Labels: bulkException.Labels}
+				if insertKept(ops[i], opErr) {
+					written[i] = utils.Existing_record_kept
+					continue
+				}
+				results[i] = opErr
+			}
+		} else if bulkErr != nil {
+			utils.PrintLogErrorContext(ctx, bulkErr, componentMessage, methodMsg, fmt.Sprintf("Error writing %d records - Database '%s' - Collection '%s'", len(models), target.dbName, target.colName))
+			for _, i := range modelOps {
+				results[i] = bulkErr
+			}
+		}
+	}
+	for i, op := range ops {
+		failure := operationFailures[op.event.OperationType]
+		countOperation(op.event.OperationType, op.target, results[i])
+		switch {
+		case results[i] != nil:
+			utils.PrintLogErrorContext(op.ctx, results[i], componentMessage, methodMsg, failure)
+			continue
+		case len(written[i]) > 0:
+			utils.PrintLogInfoContext(op.ctx, componentMessage, methodMsg, fmt.Sprintf(written[i], op.target.rawID, op.target.dbName, op.target.colName))
+		}
+		observeLatencies(op.event, op.target.dbName, op.appliedAt)
+	}
+	return results
+}
+
+// insertKept tells a duplicate key on the insert of a record in idempotent mode: the
+// record is already in place, written by an earlier attempt of the bulk write or by a
+// redelivered event, and is kept as it is.
+func insertKept(op *bulkOperation, writeErr error) bool {
+	return op.event.OperationType == "new" && writeMode(config.Rdb.Insertmode) == WriteModeIdempotent && mongo.IsDuplicateKeyError(writeErr)
+}
+
+// existingRecords reads which of the records of the operations are in the collection.
+func existingRecords(ctx context.Context, col *mongo.Collection, ops []*bulkOperation) (map[string]bool, error) {
+	ids := make(bson.A, 0, len(ops))
+	for _, op := range ops {
+		ids = append(ids, op.target.id)
+	}
+	var documents []bson.D
+	err := withRetry(ctx, "existingRecords", func() error {
+		readCtx, cancel := boundedContext(ctx)
+		defer cancel()
+		defer observeDuration("find", time.Now())
+		cursor, findErr := col.Find(readCtx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+		if findErr != nil {
+			return findErr
+		}
+		documents = nil
+		return cursor.All(readCtx, &documents)
+	})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(documents))
+	for _, document := range documents {
+		existing[idText(documentID(document))] = true
+	}
+	return existing, nil
+}
+
+// writeModel turns an operation into a write model following the write mode of its
+// operation type, together with the message to log once it is written. Operations
+// that are a no-op for a record that exists, or does not, get no model.
+func writeModel(op *bulkOperation, exists bool) (mongo.WriteModel, string, error) {
+	methodMsg := "writeModel"
+	target := op.target
+	switch t := op.event.OperationType; t {
+	case "new":
+		switch mode := writeMode(config.Rdb.Insertmode); {
+		case mode == WriteModeUpsert && exists:
+			return mongo.NewReplaceOneModel().SetFilter(target.filter()).SetReplacement(op.document).SetUpsert(true), utils.Existing_record_replaced, nil
+		case mode == WriteModeUpsert:
+			return mongo.NewReplaceOneModel().SetFilter(target.filter()).SetReplacement(op.document).SetUpsert(true), utils.Successful_insertion, nil
+		case exists && mode == WriteModeIdempotent:
+			utils.PrintLogInfoContext(op.ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Existing_record_kept, target.rawID, target.dbName, target.colName))
+			return nil, "", nil
+		default:
+			return mongo.NewInsertOneModel().SetDocument(op.document), utils.Successful_insertion, nil
+		}
+	case "update":
+		switch mode := writeMode(config.Rdb.Updatemode); {
+		case exists:
+			return mongo.NewReplaceOneModel().SetFilter(target.filter()).SetReplacement(op.document), utils.Successful_update, nil
+		case mode == WriteModeUpsert:
+			return mongo.NewReplaceOneModel().SetFilter(target.filter()).SetReplacement(op.document).SetUpsert(true), utils.Successful_upsert, nil
+		case mode == WriteModeIdempotent:
+			utils.PrintLogWarnContext(op.ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Missing_record_skipped, target.rawID, target.dbName, target.colName))
+			return nil, "", nil
+		default:
+			utils.PrintLogErrorContext(op.ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Error_update_missing_record_in_RDB, target.rawID, target.dbName, target.colName))
+			return nil, "", ErrRecordNotFound
+		}
+	case OperationMerge, OperationPatch:
+		switch {
+		case exists:
+			return mongo.NewUpdateOneModel().SetFilter(target.filter()).SetUpdate(op.patch.update()), utils.Successful_update, nil
+		case writeMode(config.Rdb.Updatemode) == WriteModeIdempotent:
+			utils.PrintLogWarnContext(op.ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Missing_record_skipped, target.rawID, target.dbName, target.colName))
+			return nil, "", nil
+		default:
+			utils.PrintLogErrorContext(op.ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Error_update_missing_record_in_RDB, target.rawID, target.dbName, target.colName))
+			return nil, "", ErrRecordNotFound
+		}
+	case "delete":
+		switch {
+		case exists:
+			return mongo.NewDeleteOneModel().SetFilter(target.filter()), utils.Successful_delete, nil
+		case writeMode(config.Rdb.Deletemode) == WriteModeStrict:
+			utils.PrintLogErrorContext(op.ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Error_delete_missing_record_in_RDB, target.rawID, target.dbName, target.colName))
+			return nil, "", ErrRecordNotFound
+		default:
+			utils.PrintLogWarnContext(op.ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Missing_record_not_deleted, target.rawID, target.dbName, target.colName))
+			return nil, "", nil
+		}
+	default:
+		return nil, "", fmt.Errorf("%w: '%s'", ErrUnsupportedOperation, t)
+	}
+}
@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func bulkTestOperation(operationType string) *bulkOperation {
+	return newBulkOperation(0, BatchEvent{
+		Ctx:   context.Background(),
+		Event: utils.RecordEvent{Id: id, DBName: repo, OperationType: operationType, RecordContent: `{"name":"test"}`},
+	})
+}
+
+func TestNewBulkOperation(t *testing.T) {
+	Convey("Check an event becomes a document with _id and metadata", t, func() {
+		op := bulkTestOperation("new")
+		So(op.err, ShouldBeNil)
+		So(op.document["name"], ShouldEqual, "test")
+		So(op.document["_id"], ShouldEqual, op.target.id)
+		So(op.document[metadataField], ShouldNotBeNil)
+	})
+
+	Convey("Check an unsupported operation type is reported", t, func() {
+		op := bulkTestOperation("rename")
+		So(errors.Is(op.err, ErrUnsupportedOperation), ShouldBeTrue)
+	})
+
+	Convey("Check an invalid record content is reported", t, func() {
+		op := newBulkOperation(0, BatchEvent{Ctx: context.Background(), Event: utils.RecordEvent{Id: id, DBName: repo, OperationType: "new", RecordContent: "{"}})
+		So(op.err, ShouldNotBeNil)
+	})
+}
+
+func TestWriteModel(t *testing.T) {
+	modes := config.Rdb
+	defer func() { config.Rdb = modes }()
+
+	Convey("Check strict mode fails on missing records", t, func() {
+		config.Rdb.Updatemode, config.Rdb.Deletemode = WriteModeStrict, WriteModeStrict
+		_, _, updateErr := writeModel(bulkTestOperation("update"), false)
+		So(updateErr, ShouldEqual, ErrRecordNotFound)
+		_, _, deleteErr := writeModel(bulkTestOperation("delete"), false)
+		So(deleteErr, ShouldEqual, ErrRecordNotFound)
+	})
+
+	Convey("Check idempotent mode skips records already in place", t, func() {
+		config.Rdb.Insertmode, config.Rdb.Updatemode, config.Rdb.Deletemode = WriteModeIdempotent, WriteModeIdempotent, WriteModeIdempotent
+		model, _, err := writeModel(bulkTestOperation("new"), true)
+		So(model, ShouldBeNil)
+		So(err, ShouldBeNil)
+		model, _, err = writeModel(bulkTestOperation("update"), false)
+		So(model, ShouldBeNil)
+		So(err, ShouldBeNil)
+		model, _, err = writeModel(bulkTestOperation("delete"), false)
+		So(model, ShouldBeNil)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Check upsert mode replaces or creates records", t, func() {
+		config.Rdb.Insertmode, config.Rdb.Updatemode = WriteModeUpsert, WriteModeUpsert
+		model, message, _ := writeModel(bulkTestOperation("new"), true)
+		So(model, ShouldHaveSameTypeAs, &mongo.ReplaceOneModel{})
+		So(message, ShouldEqual, utils.Existing_record_replaced)
+		model, message, _ = writeModel(bulkTestOperation("update"), false)
+		So(*model.(*mongo.ReplaceOneModel).Upsert, ShouldBeTrue)
+		So(message, ShouldEqual, utils.Successful_upsert)
+	})
+
+	Convey("Check records are inserted and deleted with their own models", t, func() {
+		config.Rdb.Insertmode = WriteModeStrict
+		model, _, _ := writeModel(bulkTestOperation("new"), false)
+		So(model, ShouldHaveSameTypeAs, &mongo.InsertOneModel{})
+		model, _, _ = writeModel(bulkTestOperation("delete"), true)
+		So(model, ShouldHaveSameTypeAs, &mongo.DeleteOneModel{})
+	})
+}
+
+func TestInsertKept(t *testing.T) {
+	modes := config.Rdb
+	defer func() { config.Rdb = modes }()
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}}}
+
+	Convey("Check a duplicate key on insert keeps the record in idempotent mode", t, func() {
+		config.Rdb.Insertmode = WriteModeIdempotent
+		So(insertKept(bulkTestOperation("new"), duplicate), ShouldBeTrue)
+		So(insertKept(bulkTestOperation("update"), duplicate), ShouldBeFalse)
+		So(insertKept(bulkTestOperation("new"), mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 121}}}), ShouldBeFalse)
+	})
+
+	Convey("Check a duplicate key on insert fails in strict mode", t, func() {
+		config.Rdb.Insertmode = WriteModeStrict
+		So(insertKept(bulkTestOperation("new"), duplicate), ShouldBeFalse)
+	})
+}
+
+func TestBulkPatchOperation(t *testing.T) {
+	Convey("Check merge patches are written as updates", t, func() {
+		op := newBulkOperation(0, BatchEvent{Ctx: context.Background(), Event: utils.RecordEvent{Id: id, DBName: repo, OperationType: OperationMerge, RecordContent: `{"name":"new"}`}})
+		So(op.err, ShouldBeNil)
+		model, _, err := writeModel(op, true)
+		So(err, ShouldBeNil)
+		So(model, ShouldHaveSameTypeAs, &mongo.UpdateOneModel{})
+	})
+
+	Convey("Check patches with conditions are left to be applied one by one", t, func() {
+		op := newBulkOperation(0, BatchEvent{Ctx: context.Background(), Event: utils.RecordEvent{Id: id, DBName: repo, OperationType: OperationPatch, RecordContent: `[{"op":"test","path":"/version","value":1}]`}})
+		So(op.err, ShouldEqual, ErrPreconditionNotChecked)
+	})
+}
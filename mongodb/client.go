@@ -5,46 +5,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 	configuration "xqledger/rdboperator/configuration"
+	"xqledger/rdboperator/metrics"
+	"xqledger/rdboperator/tracing"
 	utils "xqledger/rdboperator/utils"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const componentMessage = "MongoDB Client"
 
 var config = configuration.GlobalConfiguration
-var client *mongo.Client = nil
 
-func getRDBClient() (*mongo.Client, context.Context, error) {
-	methodMsg := "getRDBClient"
-	if client != nil {
-		utils.PrintLogInfo(componentMessage, methodMsg, "Existing MongoDB Client obtained OK")
-		return client, nil, nil
-	}
-	uri := fmt.Sprintf(
-		"mongodb://%s:%s@%s:%d/TestRepository?authSource=admin&w=majority&retryWrites=true",
-		config.Rdb.Username,
-		config.Rdb.Password,
-		config.Rdb.Host,
-		27017,
-	)
-	c, _ := context.WithTimeout(context.Background(), time.Duration(config.Rdb.Timeout)*time.Second)
-	clientOptions := options.Client().ApplyURI(uri)
-	clientOptions = clientOptions.SetMaxPoolSize(uint64(config.Rdb.Poolsize))
-	client, err := mongo.Connect(c, clientOptions)
-	if err != nil {
-		utils.PrintLogError(err, componentMessage, methodMsg, "Error connecting to MongoDB")
-		return nil, nil, err
-	}
-	utils.PrintLogInfo(componentMessage, methodMsg, "New MongoDB Client obtained OK")
-	return client, c, nil
-}
+var tracer = tracing.Tracer("mongodb")
+
+// ErrUnsupportedOperation is returned for events whose operation type is not one of
+// new, update, delete, merge and patch
+var ErrUnsupportedOperation = errors.New("operation type not supported")
 
 // func getID(m map[string]interface{}) string {
 // 	var id = ""
@@ -57,116 +39,251 @@ func getRDBClient() (*mongo.Client, context.Context, error) {
 // }
 
 func HandleEvent(event utils.RecordEvent) error {
+	return HandleEventContext(context.Background(), event)
+}
+
+// HandleEventContext applies an event to the RDB. Every MongoDB operation gets a span,
+// child of the span in ctx, and the log lines and the stored metadata keep the
+// correlation ID in ctx.
+func HandleEventContext(ctx context.Context, event utils.RecordEvent) error {
 	methodMsg := "HandleEvent"
-	utils.PrintLogInfo(componentMessage, methodMsg, "Event received to be handled in the RDB")
+	utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, "Event received to be handled in the RDB")
+	appliedAt := time.Now()
 	var recordAsMap = make(map[string]interface{})
-	if event.OperationType != "delete" {
+	var patch recordPatch
+	switch event.OperationType {
+	case "delete":
+	case OperationMerge, OperationPatch:
+		var patchErr error
+		patch, patchErr = parsePatch(event.OperationType, event.RecordContent)
+		if patchErr != nil {
+			utils.PrintLogErrorContext(ctx, patchErr, componentMessage, methodMsg, "Error turning patch into an update")
+			return patchErr
+		}
+		patch.setField(metadataField, eventMetadata(event, utils.CorrelationIDFrom(ctx), appliedAt))
+	default:
 		mapErr := json.Unmarshal([]byte(event.RecordContent), &recordAsMap)
 		if mapErr != nil {
-			utils.PrintLogError(mapErr, componentMessage, methodMsg, "Error unmarshaling record to map")
+			utils.PrintLogErrorContext(ctx, mapErr, componentMessage, methodMsg, "Error unmarshaling record to map")
 			return mapErr
 		}
+		recordAsMap[metadataField] = eventMetadata(event, utils.CorrelationIDFrom(ctx), appliedAt)
+	}
+	target, targetErr := resolveTarget(event.DBName, event.Group, event.Id)
+	if targetErr != nil {
+		utils.PrintLogErrorContext(ctx, targetErr, componentMessage, methodMsg, "Error resolving record location in RDB")
+		return targetErr
 	}
-	rdbClient, ctx, err := getRDBClient()
+	rdbClient, err := getRDBClient()
 	if err != nil {
-		utils.PrintLogError(err, componentMessage, methodMsg, utils.Error_unmarshalling_RDB)
+		utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_unmarshalling_RDB)
 		return err
 	} else {
 		switch t := event.OperationType; t {
 		case "new":
-			_, err := insertRecord(rdbClient, ctx, event.DBName, event.Group, event.Id, recordAsMap)
+			err := withRetry(ctx, methodMsg, timed(ctx, "insert", target, func(ctx context.Context) error {
+				return insertRecord(rdbClient, ctx, target, recordAsMap)
+			}))
+			countOperation(t, target, err)
 			if err != nil {
-				utils.PrintLogError(err, componentMessage, methodMsg, utils.Error_inserting_record_in_RDB)
+				utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_inserting_record_in_RDB)
 				return err
 			}
 		case "update":
-			err := updateRecord(rdbClient, ctx, event.DBName, event.Group, event.Id, recordAsMap)
+			err := withRetry(ctx, methodMsg, timed(ctx, "update", target, func(ctx context.Context) error {
+				return updateRecord(rdbClient, ctx, target, recordAsMap)
+			}))
+			countOperation(t, target, err)
+			if err != nil {
+				utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_updating_record_in_RDB)
+				return err
+			}
+		case OperationMerge, OperationPatch:
+			err := withRetry(ctx, methodMsg, timed(ctx, "update", target, func(ctx context.Context) error {
+				return patchRecord(rdbClient, ctx, target, patch)
+			}))
+			countOperation(t, target, err)
 			if err != nil {
-				utils.PrintLogError(err, componentMessage, methodMsg, utils.Error_updating_record_in_RDB)
+				utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_updating_record_in_RDB)
 				return err
 			}
 		case "delete":
-			err := deleteRecord(rdbClient, ctx, event.DBName, event.Group, event.Id)
+			err := withRetry(ctx, methodMsg, timed(ctx, "delete", target, func(ctx context.Context) error {
+				return deleteRecord(rdbClient, ctx, target)
+			}))
+			countOperation(t, target, err)
 			if err != nil {
-				utils.PrintLogError(err, componentMessage, methodMsg, utils.Error_deletion_record_in_RDB)
+				utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_deletion_record_in_RDB)
 				return err
 			}
 		default:
-			utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Operation not supported: %s", t))
+			unsupportedErr := fmt.Errorf("%w: '%s'", ErrUnsupportedOperation, t)
+			utils.PrintLogErrorContext(ctx, unsupportedErr, componentMessage, methodMsg, "Error applying event to RDB")
+			return unsupportedErr
 		}
 	}
+	observeLatencies(event, target.dbName, appliedAt)
 	return nil
 }
 
-func insertRecord(client *mongo.Client, ctx context.Context, dbName string, colName string, _id string, recordAsMap map[string]interface{}) (string, error) {
-	methodMsg := "insertRecord"
-	cleanDbName := strings.ReplaceAll(dbName, ".", "")
-	if !(len(colName) > 0) {
-		colName = "main"
+// timed gives a single RDB operation its own deadline and span, and records its
+// latency.
+func timed(parent context.Context, operation string, target recordTarget, op func(ctx context.Context) error) func() error {
+	return func() error {
+		ctx, cancel := boundedContext(parent)
+		defer cancel()
+		ctx, span := tracer.Start(ctx, "mongodb "+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "mongodb"),
+				attribute.String("db.name", target.dbName),
+				attribute.String("db.mongodb.collection", target.colName),
+				attribute.String("db.operation", operation),
+			))
+		defer observeDuration(operation, time.Now())
+		err := op(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		return err
 	}
-	col := client.Database(cleanDbName).Collection(colName)
+}
 
-	if len(_id) > 0 {
-		oid, _ := primitive.ObjectIDFromHex(_id)
-		recordAsMap["_id"] = oid
-	} else {
-		err := errors.New("ID not provided")
-		return "", err
-	}
+func observeDuration(operation string, start time.Time) {
+	metrics.RDBOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
 
-	result, insertErr := col.InsertOne(ctx, recordAsMap)
-	if insertErr != nil {
-		utils.PrintLogError(insertErr, componentMessage, methodMsg, "Error inserting record in RDB")
-		return "", insertErr
-	}
-	id := fmt.Sprintf("%v", result.InsertedID)
-	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf(utils.Successful_insertion, id, dbName, colName))
+func countOperation(operationType string, target recordTarget, err error) {
+	metrics.RDBOperations.WithLabelValues(operationType, metrics.Outcome(err), target.dbName).Inc()
+}
 
-	return id, nil
+func insertRecord(client *mongo.Client, ctx context.Context, target recordTarget, recordAsMap map[string]interface{}) error {
+	methodMsg := "insertRecord"
+	col := target.collection(client)
+	recordAsMap["_id"] = target.id
+	switch writeMode(config.Rdb.Insertmode) {
+	case WriteModeUpsert:
+		result, replaceErr := col.ReplaceOne(ctx, target.filter(), recordAsMap, options.Replace().SetUpsert(true))
+		if replaceErr != nil {
+			utils.PrintLogErrorContext(ctx, replaceErr, componentMessage, methodMsg, "Error inserting record in RDB")
+			return replaceErr
+		}
+		if result.MatchedCount > 0 {
+			utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Existing_record_replaced, target.rawID, target.dbName, target.colName))
+			return nil
+		}
+	default:
+		if writeMode(config.Rdb.Insertmode) == WriteModeIdempotent && mongo.SessionFromContext(ctx) != nil {
+			// A duplicate key aborts the transaction, so inside one the record is looked up first
+			count, countErr := col.CountDocuments(ctx, target.filter(), options.Count().SetLimit(1))
+			if countErr != nil {
+				utils.PrintLogErrorContext(ctx, countErr, componentMessage, methodMsg, "Error inserting record in RDB")
+				return countErr
+			}
+			if count > 0 {
+				utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Existing_record_kept, target.rawID, target.dbName, target.colName))
+				return nil
+			}
+		}
+		_, insertErr := col.InsertOne(ctx, recordAsMap)
+		if insertErr != nil {
+			if mongo.IsDuplicateKeyError(insertErr) && writeMode(config.Rdb.Insertmode) == WriteModeIdempotent {
+				utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Existing_record_kept, target.rawID, target.dbName, target.colName))
+				return nil
+			}
+			utils.PrintLogErrorContext(ctx, insertErr, componentMessage, methodMsg, "Error inserting record in RDB")
+			return insertErr
+		}
+	}
+	utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Successful_insertion, target.rawID, target.dbName, target.colName))
+	return nil
 }
 
-func updateRecord(client *mongo.Client, ctx context.Context, dbName string, colName string, _id string, recordAsMap map[string]interface{}) error {
+func updateRecord(client *mongo.Client, ctx context.Context, target recordTarget, recordAsMap map[string]interface{}) error {
 	methodMsg := "updateRecord"
-	cleanDbName := strings.ReplaceAll(dbName, ".", "")
-	if !(len(colName) > 0) {
-		colName = "main"
+	col := target.collection(client)
+	recordAsMap["_id"] = target.id
+	mode := writeMode(config.Rdb.Updatemode)
+	result, replaceErr := col.ReplaceOne(ctx, target.filter(), recordAsMap, options.Replace().SetUpsert(mode == WriteModeUpsert))
+	if replaceErr != nil {
+		utils.PrintLogErrorContext(ctx, replaceErr, componentMessage, methodMsg, "Error updating record in RDB")
+		return replaceErr
 	}
-	col := client.Database(cleanDbName).Collection(colName)
+	return checkUpdateResult(ctx, result, mode, methodMsg, target)
+}
+
+// checkUpdateResult turns the matched and modified counts of an update into its
+// outcome: a missing record is an error in strict mode and a no-op in idempotent mode.
+func checkUpdateResult(ctx context.Context, result *mongo.UpdateResult, mode string, methodMsg string, target recordTarget) error {
+	switch {
+	case result.UpsertedCount > 0:
+		utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Successful_upsert, target.rawID, target.dbName, target.colName))
+	case result.MatchedCount == 0 && mode == WriteModeIdempotent:
+		utils.PrintLogWarnContext(ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Missing_record_skipped, target.rawID, target.dbName, target.colName))
+	case result.MatchedCount == 0:
+		utils.PrintLogErrorContext(ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Error_update_missing_record_in_RDB, target.rawID, target.dbName, target.colName))
+		return ErrRecordNotFound
+	case result.ModifiedCount == 0:
+		utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Unchanged_update, target.rawID, target.dbName, target.colName))
+	default:
+		utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Successful_update, target.rawID, target.dbName, target.colName))
+	}
+	return nil
+}
 
-	if len(_id) > 0 { // Case for update
-		oid, idErr := primitive.ObjectIDFromHex(_id)
-		if idErr != nil {
-			utils.PrintLogError(idErr, componentMessage, methodMsg, "Error converting provided id: "+_id)
-			return idErr
+// patchRecord applies a merge or patch event. Patches need the record in every write
+// mode but idempotent, where a missing record is skipped.
+func patchRecord(client *mongo.Client, ctx context.Context, target recordTarget, patch recordPatch) error {
+	methodMsg := "patchRecord"
+	col := target.collection(client)
+	result, updateErr := col.UpdateOne(ctx, patch.filter(target), patch.update())
+	if updateErr != nil {
+		utils.PrintLogErrorContext(ctx, updateErr, componentMessage, methodMsg, "Error patching record in RDB")
+		return updateErr
+	}
+	if result.MatchedCount == 0 && len(patch.conditions) > 0 {
+		// Either the record is missing or it does not pass the patch
+		count, countErr := col.CountDocuments(ctx, target.filter(), options.Count().SetLimit(1))
+		if countErr != nil {
+			utils.PrintLogErrorContext(ctx, countErr, componentMessage, methodMsg, "Error patching record in RDB")
+			return countErr
 		}
-		recordAsMap["_id"] = oid
-		_, replaceErr := col.ReplaceOne(ctx, bson.M{"_id": oid}, recordAsMap)
-		if replaceErr != nil {
-			utils.PrintLogError(replaceErr, componentMessage, methodMsg, "Error inserting record in RDB")
-			return replaceErr
+		if count > 0 {
+			utils.PrintLogErrorContext(ctx, ErrPatchTestFailed, componentMessage, methodMsg, fmt.Sprintf(utils.Error_patch_test_failed_in_RDB, target.rawID, target.dbName, target.colName))
+			return ErrPatchTestFailed
 		}
-		utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf(utils.Successful_update, _id, dbName, colName))
-		return nil
-	} else { // Case for new record
-		err := errors.New("ID not provided")
-		utils.PrintLogError(err, componentMessage, methodMsg, "ID record not provided")
-		return err
 	}
-
-	return nil
+	mode := writeMode(config.Rdb.Updatemode)
+	if mode != WriteModeIdempotent {
+		mode = WriteModeStrict
+	}
+	return checkUpdateResult(ctx, result, mode, methodMsg, target)
 }
 
-func deleteRecord(client *mongo.Client, ctx context.Context, dbName string, colName string, _id string) error {
+func deleteRecord(client *mongo.Client, ctx context.Context, target recordTarget) error {
 	methodMsg := "deleteRecord"
-	if !(len(colName) > 0) {
-		colName = "main"
-	}
-	col := client.Database(dbName).Collection(colName)
-	_, delErr := col.DeleteOne(ctx, bson.M{"_id": _id})
+	col := target.collection(client)
+	result, delErr := col.DeleteOne(ctx, target.filter())
 	if delErr != nil {
-		utils.PrintLogError(delErr, componentMessage, methodMsg, fmt.Sprintf("Error deleting record with ID '%s' - Database '%s' - Collection '%s'", _id, dbName, colName))
+		utils.PrintLogErrorContext(ctx, delErr, componentMessage, methodMsg, fmt.Sprintf("Error deleting record with ID '%s' - Database '%s' - Collection '%s'", target.rawID, target.dbName, target.colName))
 		return delErr
 	}
-	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf(utils.Successful_delete, _id, dbName, colName))
+	return checkDeleteResult(ctx, result, writeMode(config.Rdb.Deletemode), methodMsg, target)
+}
+
+// checkDeleteResult reports deletes that removed nothing: an error in strict mode and
+// a no-op in any other mode.
+func checkDeleteResult(ctx context.Context, result *mongo.DeleteResult, mode string, methodMsg string, target recordTarget) error {
+	if result.DeletedCount > 0 {
+		utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Successful_delete, target.rawID, target.dbName, target.colName))
+		return nil
+	}
+	if mode == WriteModeStrict {
+		utils.PrintLogErrorContext(ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Error_delete_missing_record_in_RDB, target.rawID, target.dbName, target.colName))
+		return ErrRecordNotFound
+	}
+	utils.PrintLogWarnContext(ctx, ErrRecordNotFound, componentMessage, methodMsg, fmt.Sprintf(utils.Missing_record_not_deleted, target.rawID, target.dbName, target.colName))
 	return nil
 }
@@ -1,6 +1,8 @@
 package mongodb
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 	utils "xqledger/rdboperator/utils"
@@ -91,4 +93,21 @@ func TestHandleDeleteEvent(t *testing.T) {
 		So(err, ShouldBeNil)
 	})
 
-}
\ No newline at end of file
+}
+func TestHandleRecordSetRollback(t *testing.T) {
+
+	Convey("Check a failing write rolls back the record set", t, func() {
+		event := getEvent()
+		event.Id = "123456789123456789123457"
+		// The second insert of the same record fails after the first one is written
+		err := HandleRecordSetContext(context.Background(), []utils.RecordEvent{event, event})
+		if errors.Is(err, ErrTransactionsNotSupported) {
+			SkipSo(err, ShouldNotBeNil)
+			return
+		}
+		So(err, ShouldNotBeNil)
+		_, err = GetRecord(context.Background(), event.DBName, event.Group, event.Id)
+		So(errors.Is(err, ErrRecordNotFound), ShouldBeTrue)
+	})
+
+}
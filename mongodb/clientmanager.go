@@ -0,0 +1,123 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// clientManager owns the single MongoDB client of the process. The client is safe
+// for concurrent use and keeps its own connection pool, so it is created once and
+// shared by every operation.
+type clientManager struct {
+	mu     sync.RWMutex
+	client *mongo.Client
+}
+
+var manager = &clientManager{}
+
+// Connect creates the MongoDB client and pings the primary. It is meant to be called
+// on startup so that a wrong configuration is reported straight away.
+func Connect() error {
+	_, err := manager.get()
+	return err
+}
+
+// Close disconnects the MongoDB client, if it was ever connected.
+func Close() error {
+	return manager.close()
+}
+
+// operationContext returns the context for a single operation, with the deadline
+// configured in Rdb.Timeout.
+func operationContext() (context.Context, context.CancelFunc) {
+	return boundedContext(context.Background())
+}
+
+// boundedContext is like operationContext for an operation done on behalf of a
+// caller, keeping its cancellation and trace.
+func boundedContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, time.Duration(config.Rdb.Timeout)*time.Second)
+}
+
+// Ping checks that the primary answers within Rdb.Timeout.
+func Ping(ctx context.Context) error {
+	client, err := getRDBClient()
+	if err != nil {
+		return err
+	}
+	pingCtx, cancel := boundedContext(ctx)
+	defer cancel()
+	defer observeDuration("ping", time.Now())
+	return client.Ping(pingCtx, readpref.Primary())
+}
+
+func getRDBClient() (*mongo.Client, error) {
+	return manager.get()
+}
+
+func (m *clientManager) get() (*mongo.Client, error) {
+	m.mu.RLock()
+	client := m.client
+	m.mu.RUnlock()
+	if client != nil {
+		return client, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client != nil {
+		return m.client, nil
+	}
+	client, err := m.connect()
+	if err != nil {
+		return nil, err
+	}
+	m.client = client
+	return client, nil
+}
+
+func (m *clientManager) connect() (*mongo.Client, error) {
+	methodMsg := "connect"
+	clientOptions := options.Client().ApplyURI(buildURI())
+	clientOptions = clientOptions.SetMaxPoolSize(uint64(config.Rdb.Poolsize))
+	ctx, cancel := operationContext()
+	defer cancel()
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, "Error connecting to MongoDB")
+		return nil, err
+	}
+	pingErr := client.Ping(ctx, readpref.Primary())
+	if pingErr != nil {
+		utils.PrintLogError(pingErr, componentMessage, methodMsg, "Error pinging MongoDB")
+		client.Disconnect(ctx)
+		return nil, pingErr
+	}
+	utils.PrintLogInfo(componentMessage, methodMsg, "New MongoDB Client obtained OK")
+	return client, nil
+}
+
+func (m *clientManager) close() error {
+	methodMsg := "Close"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client == nil {
+		return nil
+	}
+	ctx, cancel := operationContext()
+	defer cancel()
+	err := m.client.Disconnect(ctx)
+	m.client = nil
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, "Error disconnecting from MongoDB")
+		return err
+	}
+	utils.PrintLogInfo(componentMessage, methodMsg, "MongoDB Client disconnected OK")
+	return nil
+}
@@ -0,0 +1,25 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOperationContext(t *testing.T) {
+	Convey("Check every operation gets its own deadline", t, func() {
+		ctx, cancel := operationContext()
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		So(ok, ShouldBeTrue)
+		So(deadline, ShouldHappenWithin, time.Duration(config.Rdb.Timeout)*time.Second+time.Second, time.Now())
+	})
+}
+
+func TestCloseWithoutClient(t *testing.T) {
+	Convey("Check closing a manager that never connected is a no-op", t, func() {
+		err := (&clientManager{}).close()
+		So(err, ShouldBeNil)
+	})
+}
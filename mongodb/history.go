@@ -0,0 +1,152 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrHistoryDisabled is returned when reading the history without
+// Rdb.Historycollection
+var ErrHistoryDisabled = errors.New("status history not enabled")
+
+// StatusTransition is an entry of the status history of a record: what was done with
+// an event and the status the record was left in. The history of a record, ordered
+// by time, tells how it reached its current state.
+type StatusTransition struct {
+	RecordID      string `bson:"record_id" json:"record_id"`           // Name of the file/record in the database
+	Group         string `bson:"group" json:"group"`                   // Name of the Git tree/folder
+	Operation     string `bson:"operation" json:"operation"`           // Values: (new | update | delete | merge | patch)
+	EventStatus   string `bson:"event_status" json:"event_status"`     // Status of the incoming event
+	Action        string `bson:"action" json:"action"`                 // Values: (apply | skip | review)
+	Status        string `bson:"status" json:"status"`                 // Resulting status: COMPLETE | NOTVALID | INCOMPLETE
+	Reason        string `bson:"reason" json:"reason"`                 // empty if Status == COMPLETE
+	User          string `bson:"user" json:"user"`                     // email of the individual performing the change
+	CorrelationID string `bson:"correlation_id" json:"correlation_id"` // ID of the event in the logs of the operator
+	Time          int64  `bson:"time" json:"time"`                     // Time of the transition
+}
+
+// historyEnabled reports whether transitions are kept, in Rdb.Historycollection.
+func historyEnabled() bool {
+	return len(config.Rdb.Historycollection) > 0
+}
+
+// Transition is a status change of the record of an event, with the context of the
+// event, to be appended to the history of the record.
+type Transition struct {
+	Ctx    context.Context
+	Event  utils.RecordEvent
+	Action string
+	Status string
+	Reason string
+}
+
+// RecordTransition appends a transition to the status history of the record of an
+// event, kept in Rdb.Historycollection of the record database. It does nothing when
+// no history collection is configured.
+func RecordTransition(ctx context.Context, event utils.RecordEvent, action string, status string, reason string) error {
+	return RecordTransitions(ctx, []Transition{{Ctx: ctx, Event: event, Action: action, Status: status, Reason: reason}})
+}
+
+// RecordTransitions is RecordTransition for the events of a batch, with one write per
+// record database. Failures are logged for each transition, and the first one is
+// returned. The transitions are written even once ctx is cancelled, as they record
+// what has already been done.
+func RecordTransitions(ctx context.Context, transitions []Transition) error {
+	methodMsg := "RecordTransition"
+	ctx = context.WithoutCancel(ctx)
+	if !historyEnabled() || len(transitions) == 0 {
+		return nil
+	}
+	rdbClient, err := getRDBClient()
+	if err != nil {
+		for _, t := range transitions {
+			utils.PrintLogErrorContext(t.Ctx, err, componentMessage, methodMsg, fmt.Sprintf("Error recording status %s of record with ID '%s' - Database '%s'", t.Status, t.Event.Id, databaseName(t.Event.DBName)))
+		}
+		return err
+	}
+	var dbNames []string
+	byDatabase := make(map[string][]Transition)
+	documents := make(map[string][]interface{})
+	for _, t := range transitions {
+		dbName := databaseName(t.Event.DBName)
+		if _, found := byDatabase[dbName]; !found {
+			dbNames = append(dbNames, dbName)
+		}
+		byDatabase[dbName] = append(byDatabase[dbName], t)
+		documents[dbName] = append(documents[dbName], StatusTransition{
+			RecordID:      t.Event.Id,
+			Group:         collectionName(t.Event.Group),
+			Operation:     t.Event.OperationType,
+			EventStatus:   t.Event.Status,
+			Action:        t.Action,
+			Status:        t.Status,
+			Reason:        t.Reason,
+			User:          t.Event.User,
+			CorrelationID: utils.CorrelationIDFrom(t.Ctx),
+			Time:          utils.GetEpochNow(),
+		})
+	}
+	var firstErr error
+	for _, dbName := range dbNames {
+		insertErr := withRetry(ctx, methodMsg, func() error {
+			writeCtx, cancel := boundedContext(ctx)
+			defer cancel()
+			defer observeDuration("history", time.Now())
+			_, insertErr := rdbClient.Database(dbName).Collection(config.Rdb.Historycollection).InsertMany(writeCtx, documents[dbName])
+			return insertErr
+		})
+		if insertErr == nil {
+			continue
+		}
+		for _, t := range byDatabase[dbName] {
+			utils.PrintLogErrorContext(t.Ctx, insertErr, componentMessage, methodMsg, fmt.Sprintf("Error recording status %s of record with ID '%s' - Database '%s'", t.Status, t.Event.Id, dbName))
+		}
+		if firstErr == nil {
+			firstErr = insertErr
+		}
+	}
+	return firstErr
+}
+
+// GetRecordHistory reads the status history of a record, oldest first.
+func GetRecordHistory(ctx context.Context, dbName string, group string, id string) ([]StatusTransition, error) {
+	methodMsg := "GetRecordHistory"
+	if !historyEnabled() {
+		return nil, ErrHistoryDisabled
+	}
+	if len(dbName) == 0 {
+		return nil, ErrMissingDatabase
+	}
+	if len(id) == 0 {
+		return nil, ErrMissingID
+	}
+	rdbClient, err := getRDBClient()
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"record_id": id, "group": collectionName(group)}
+	findOptions := options.Find().SetSort(bson.D{{Key: "time", Value: 1}, {Key: "_id", Value: 1}})
+	var history []StatusTransition
+	err = withRetry(ctx, methodMsg, func() error {
+		readCtx, cancel := boundedContext(ctx)
+		defer cancel()
+		defer observeDuration("find", time.Now())
+		cursor, findErr := rdbClient.Database(databaseName(dbName)).Collection(config.Rdb.Historycollection).Find(readCtx, filter, findOptions)
+		if findErr != nil {
+			return findErr
+		}
+		history = nil
+		return cursor.All(readCtx, &history)
+	})
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, fmt.Sprintf("Error reading history of record with ID '%s' - Database '%s' - Collection '%s'", id, dbName, group))
+		return nil, err
+	}
+	return history, nil
+}
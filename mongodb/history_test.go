@@ -0,0 +1,41 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStatusTransition(t *testing.T) {
+	Convey("Check nothing is written or read without a history collection ", t, func() {
+		collection := config.Rdb.Historycollection
+		defer func() { config.Rdb.Historycollection = collection }()
+		config.Rdb.Historycollection = ""
+		// No RDB is reachable in unit tests, so any write attempt would fail
+		err := RecordTransition(context.Background(), utils.RecordEvent{Id: "1", DBName: "TestRepository"}, utils.StatusActionApply, utils.StatusComplete, "")
+		So(err, ShouldBeNil)
+		err = RecordTransitions(context.Background(), []Transition{{Ctx: context.Background(), Event: utils.RecordEvent{Id: "1", DBName: "TestRepository"}, Action: utils.StatusActionApply, Status: utils.StatusComplete}})
+		So(err, ShouldBeNil)
+		_, err = GetRecordHistory(context.Background(), "TestRepository", "main", "1")
+		So(err, ShouldEqual, ErrHistoryDisabled)
+	})
+
+	Convey("Check a transition is stored with its field names ", t, func() {
+		raw, err := bson.Marshal(StatusTransition{RecordID: "1", EventStatus: "NOTVALID", Action: "skip", Status: "NOTVALID"})
+		So(err, ShouldBeNil)
+		So(bson.Raw(raw).Lookup("record_id").StringValue(), ShouldEqual, "1")
+		So(bson.Raw(raw).Lookup("event_status").StringValue(), ShouldEqual, "NOTVALID")
+		So(bson.Raw(raw).Lookup("action").StringValue(), ShouldEqual, "skip")
+	})
+
+	Convey("Check the history of a record needs its location ", t, func() {
+		_, err := GetRecordHistory(context.Background(), "", "main", "1")
+		So(errors.Is(err, ErrMissingDatabase), ShouldBeTrue)
+		_, err = GetRecordHistory(context.Background(), "TestRepository", "main", "")
+		So(errors.Is(err, ErrMissingID), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Strategies to turn the record ID of an event into the _id stored in the RDB,
+// configured in Rdb.Idmode
+const (
+	// IDModeObjectID requires the record ID to be a 24 hex characters ObjectID
+	IDModeObjectID = "objectid"
+	// IDModeString stores the record ID as is
+	IDModeString = "string"
+	// IDModeUUID stores a UUID: the record ID itself when it is one, otherwise a
+	// name based UUID derived from it
+	IDModeUUID = "uuid"
+	// IDModeHash stores the SHA-256 of DB name, group and record ID
+	IDModeHash = "hash"
+)
+
+// ErrInvalidID is returned for record IDs that the ID mode cannot store
+var ErrInvalidID = errors.New("invalid record ID")
+
+// idMode normalises a configured ID mode, falling back to ObjectID.
+func idMode(configured string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(configured)); mode {
+	case IDModeString, IDModeUUID, IDModeHash:
+		return mode
+	default:
+		return IDModeObjectID
+	}
+}
+
+// recordID returns the _id value of a record. The result only depends on its input,
+// so new, update and delete events of a record always resolve to the same _id.
+func recordID(mode string, dbName string, group string, rawID string) (interface{}, error) {
+	switch mode {
+	case IDModeString:
+		return rawID, nil
+	case IDModeUUID:
+		id, parseErr := uuid.Parse(rawID)
+		if parseErr != nil {
+			id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(rawID))
+		}
+		return primitive.Binary{Subtype: 0x04, Data: id[:]}, nil
+	case IDModeHash:
+		hash := sha256.Sum256([]byte(dbName + "/" + group + "/" + rawID))
+		return hex.EncodeToString(hash[:]), nil
+	default:
+		oid, idErr := primitive.ObjectIDFromHex(rawID)
+		if idErr != nil {
+			return nil, fmt.Errorf("%w '%s': %v", ErrInvalidID, rawID, idErr)
+		}
+		return oid, nil
+	}
+}
@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const fileName = "firefox-release.json"
+
+func TestIDMode(t *testing.T) {
+	Convey("Check configured ID modes are normalised", t, func() {
+		So(idMode("UUID"), ShouldEqual, IDModeUUID)
+		So(idMode("string"), ShouldEqual, IDModeString)
+		So(idMode("hash"), ShouldEqual, IDModeHash)
+		So(idMode(""), ShouldEqual, IDModeObjectID)
+	})
+}
+
+func TestRecordID(t *testing.T) {
+	Convey("Check ObjectID mode rejects IDs that are not 24 hex characters", t, func() {
+		result, err := recordID(IDModeObjectID, repo, "main", id)
+		So(err, ShouldBeNil)
+		So(result, ShouldHaveSameTypeAs, primitive.ObjectID{})
+		_, err = recordID(IDModeObjectID, repo, "main", fileName)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Check string mode keeps the ID as is", t, func() {
+		result, err := recordID(IDModeString, repo, "main", fileName)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, fileName)
+	})
+
+	Convey("Check UUID mode keeps UUIDs and derives one from any other ID", t, func() {
+		existing := uuid.New()
+		result, err := recordID(IDModeUUID, repo, "main", existing.String())
+		So(err, ShouldBeNil)
+		So(result.(primitive.Binary).Data, ShouldResemble, existing[:])
+		first, _ := recordID(IDModeUUID, repo, "main", fileName)
+		second, _ := recordID(IDModeUUID, repo, "main", fileName)
+		So(first.(primitive.Binary).Subtype, ShouldEqual, 0x04)
+		So(first, ShouldResemble, second)
+	})
+
+	Convey("Check hash mode is deterministic and depends on DB name and group", t, func() {
+		first, err := recordID(IDModeHash, repo, "main", fileName)
+		So(err, ShouldBeNil)
+		So(len(first.(string)), ShouldEqual, 64)
+		second, _ := recordID(IDModeHash, repo, "main", fileName)
+		So(first, ShouldEqual, second)
+		other, _ := recordID(IDModeHash, repo, "browsers", fileName)
+		So(first, ShouldNotEqual, other)
+	})
+}
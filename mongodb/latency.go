@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"time"
+	"xqledger/rdboperator/metrics"
+	utils "xqledger/rdboperator/utils"
+)
+
+// stageLatency is the time an event spent between two stages of the pipeline.
+type stageLatency struct {
+	stage   string
+	latency time.Duration
+}
+
+// eventLatencies works out the latency of each stage of an applied event from its
+// timestamps. Stages with a missing timestamp are left out, and clock skew between
+// hosts is reported as no latency rather than a negative one.
+func eventLatencies(event utils.RecordEvent, appliedAt time.Time) []stageLatency {
+	applied := appliedAt.UnixMilli()
+	stages := []struct {
+		stage string
+		from  int64
+		to    int64
+	}{
+		{"client_to_api", event.SendingTime, event.ReceptionTime},
+		{"api_to_gitoperator", event.ReceptionTime, event.ProcessingTime},
+		{"gitoperator_to_rdb", event.ProcessingTime, applied},
+		{"total", event.SendingTime, applied},
+	}
+	var latencies []stageLatency
+	for _, s := range stages {
+		if s.from <= 0 || s.to <= 0 {
+			continue
+		}
+		from := utils.EpochToTime(s.from)
+		to := utils.EpochToTime(s.to)
+		latency := to.Sub(from)
+		if latency < 0 {
+			latency = 0
+		}
+		latencies = append(latencies, stageLatency{s.stage, latency})
+	}
+	return latencies
+}
+
+func observeLatencies(event utils.RecordEvent, dbName string, appliedAt time.Time) {
+	for _, l := range eventLatencies(event, appliedAt) {
+		metrics.EventLatency.WithLabelValues(l.stage, dbName).Observe(l.latency.Seconds())
+	}
+}
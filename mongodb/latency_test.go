@@ -0,0 +1,35 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventLatencies(t *testing.T) {
+	Convey("Check the latency of every stage of an event ", t, func() {
+		appliedAt := time.Unix(1600000010, 0)
+		event := utils.RecordEvent{SendingTime: 1600000000, ReceptionTime: 1600000001, ProcessingTime: 1600000004}
+		latencies := eventLatencies(event, appliedAt)
+		So(latencies, ShouldResemble, []stageLatency{
+			{"client_to_api", time.Second},
+			{"api_to_gitoperator", 3 * time.Second},
+			{"gitoperator_to_rdb", 6 * time.Second},
+			{"total", 10 * time.Second},
+		})
+	})
+
+	Convey("Check stages without timestamps are left out ", t, func() {
+		appliedAt := time.Unix(1600000010, 0)
+		latencies := eventLatencies(utils.RecordEvent{ProcessingTime: 1600000004000}, appliedAt)
+		So(latencies, ShouldResemble, []stageLatency{{"gitoperator_to_rdb", 6 * time.Second}})
+	})
+
+	Convey("Check clock skew does not give negative latencies ", t, func() {
+		appliedAt := time.Unix(1600000010, 0)
+		latencies := eventLatencies(utils.RecordEvent{ProcessingTime: 1600000020}, appliedAt)
+		So(latencies, ShouldResemble, []stageLatency{{"gitoperator_to_rdb", 0}})
+	})
+}
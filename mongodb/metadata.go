@@ -0,0 +1,47 @@
+package mongodb
+
+import (
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// metadataField is the field of the stored documents that keeps the RecordMetadata
+const metadataField = "_metadata"
+
+// RecordMetadata describes the last event applied to a record. It is stored next to
+// the record content so that readers can tell who changed a record and when.
+type RecordMetadata struct {
+	Operation      string `bson:"operation" json:"operation"`             // Last operation type applied (new | update | merge | patch)
+	Status         string `bson:"status" json:"status"`                   // Status of the record, COMPLETE once applied
+	User           string `bson:"user" json:"user"`                       // email of the individual performing the change
+	ProcessingTime int64  `bson:"processing_time" json:"processing_time"` // Time of processing by the Git Operator
+	AppliedTime    int64  `bson:"applied_time" json:"applied_time"`       // Time of the write in the RDB by this operator
+	CorrelationID  string `bson:"correlation_id" json:"correlation_id"`   // ID of the event in the logs of the operator
+}
+
+func eventMetadata(event utils.RecordEvent, correlationID string, appliedAt time.Time) RecordMetadata {
+	return RecordMetadata{
+		Operation:      event.OperationType,
+		Status:         utils.StatusComplete,
+		User:           event.User,
+		ProcessingTime: event.ProcessingTime,
+		AppliedTime:    appliedAt.Unix(),
+		CorrelationID:  correlationID,
+	}
+}
+
+// decodeMetadata reads the metadata element of a stored document.
+func decodeMetadata(value interface{}) (*RecordMetadata, error) {
+	raw, marshalErr := bson.Marshal(value)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+	var metadata RecordMetadata
+	unmarshalErr := bson.Unmarshal(raw, &metadata)
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,328 @@
+package mongodb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Operation types that change part of a record
+const (
+	// OperationMerge carries a JSON Merge Patch (RFC 7386) in RecordContent
+	OperationMerge = "merge"
+	// OperationPatch carries a JSON Patch (RFC 6902) in RecordContent
+	OperationPatch = "patch"
+)
+
+// ErrInvalidPatch is returned for patch documents that cannot be turned into a
+// MongoDB update
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ErrPatchTestFailed is returned when the record does not pass the test operations of
+// a JSON Patch, or lacks a value the patch replaces or removes
+var ErrPatchTestFailed = errors.New("record does not match the patch")
+
+// recordPatch is a patch turned into a MongoDB update: the values the record must
+// have go to the filter and the changes to update operators.
+type recordPatch struct {
+	conditions bson.M
+	set        bson.M
+	unset      bson.M
+	push       bson.M
+	rename     bson.M
+}
+
+func newRecordPatch() recordPatch {
+	return recordPatch{conditions: bson.M{}, set: bson.M{}, unset: bson.M{}, push: bson.M{}, rename: bson.M{}}
+}
+
+// parsePatch turns the RecordContent of a merge or patch event into a recordPatch.
+func parsePatch(operationType string, content string) (recordPatch, error) {
+	parse := parseJSONPatch
+	if operationType == OperationMerge {
+		parse = parseMergePatch
+	}
+	patch, err := parse(content)
+	if err != nil {
+		return recordPatch{}, err
+	}
+	if pathsErr := patch.checkPaths(); pathsErr != nil {
+		return recordPatch{}, pathsErr
+	}
+	return patch, nil
+}
+
+// filter is the filter of the record, restricted to the values the patch requires.
+func (p recordPatch) filter(target recordTarget) bson.M {
+	filter := target.filter()
+	for path, condition := range p.conditions {
+		filter[path] = condition
+	}
+	return filter
+}
+
+// update returns the update operators of the patch.
+func (p recordPatch) update() bson.M {
+	update := bson.M{}
+	for operator, fields := range map[string]bson.M{"$set": p.set, "$unset": p.unset, "$push": p.push, "$rename": p.rename} {
+		if len(fields) > 0 {
+			update[operator] = fields
+		}
+	}
+	return update
+}
+
+// checkPaths rejects a patch that changes a field and a field within it, or a field
+// with two operators, which MongoDB refuses as conflicting updates.
+func (p recordPatch) checkPaths() error {
+	var paths []string
+	for _, fields := range []bson.M{p.set, p.unset, p.push, p.rename} {
+		for path := range fields {
+			paths = append(paths, path)
+		}
+	}
+	for _, to := range p.rename {
+		paths = append(paths, to.(string))
+	}
+	sort.Strings(paths)
+	for i := range paths {
+		for _, other := range paths[i+1:] {
+			if overlaps(paths[i], other) {
+				return fmt.Errorf("%w: '%s' and '%s' overlap", ErrInvalidPatch, paths[i], other)
+			}
+		}
+	}
+	return nil
+}
+
+func (p recordPatch) setField(path string, value interface{}) {
+	delete(p.unset, path)
+	p.set[path] = value
+}
+
+func (p recordPatch) unsetField(path string) {
+	delete(p.set, path)
+	p.unset[path] = ""
+}
+
+// parseMergePatch follows RFC 7386: null removes a field, an object is merged into
+// the field and any other value replaces it. Nested objects become dotted paths, so
+// an object is only merged into a field that is an object or missing, while an empty
+// object replaces the field.
+func parseMergePatch(content string) (recordPatch, error) {
+	var document interface{}
+	if unmarshalErr := json.Unmarshal([]byte(content), &document); unmarshalErr != nil {
+		return recordPatch{}, unmarshalErr
+	}
+	fields, isObject := document.(map[string]interface{})
+	if !isObject {
+		return recordPatch{}, fmt.Errorf("%w: a merge patch must be a JSON object", ErrInvalidPatch)
+	}
+	patch := newRecordPatch()
+	if mergeErr := mergeFields(patch, "", fields); mergeErr != nil {
+		return recordPatch{}, mergeErr
+	}
+	return patch, nil
+}
+
+func mergeFields(patch recordPatch, prefix string, fields map[string]interface{}) error {
+	for name, value := range fields {
+		if !isPatchField(name, len(prefix) == 0) {
+			return fmt.Errorf("%w: field '%s' cannot be patched", ErrInvalidPatch, prefix+name)
+		}
+		path := prefix + name
+		switch typed := value.(type) {
+		case nil:
+			patch.unsetField(path)
+		case map[string]interface{}:
+			if len(typed) == 0 {
+				// There are no dotted paths to set, the field is set to the empty object
+				patch.setField(path, bson.M{})
+				continue
+			}
+			if mergeErr := mergeFields(patch, path+".", typed); mergeErr != nil {
+				return mergeErr
+			}
+		default:
+			patch.setField(path, value)
+		}
+	}
+	return nil
+}
+
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// parseJSONPatch follows RFC 6902 as far as MongoDB update operators go:
+//   - add sets a field, or inserts into an array at an index or at the end with "-"
+//   - remove and replace unset and set a field, which the record must have
+//   - move renames a field
+//   - test requires a scalar value, so it cannot follow a change of its field
+//
+// copy, removing or moving array elements, changing a field together with a field
+// within it, and testing objects or arrays cannot be expressed reliably and are rejected.
+func parseJSONPatch(content string) (recordPatch, error) {
+	var operations []patchOperation
+	if unmarshalErr := json.Unmarshal([]byte(content), &operations); unmarshalErr != nil {
+		return recordPatch{}, unmarshalErr
+	}
+	patch := newRecordPatch()
+	// Conditions are checked against the record before the update, so they cannot
+	// follow a change of their field
+	var written []string
+	require := func(path string, condition interface{}) error {
+		for _, changed := range written {
+			if overlaps(path, changed) {
+				return fmt.Errorf("%w: '%s' is required after it is changed", ErrInvalidPatch, path)
+			}
+		}
+		patch.conditions[path] = condition
+		return nil
+	}
+	for i, operation := range operations {
+		if operation.Path == nil {
+			return recordPatch{}, fmt.Errorf("%w: operation %d has no path", ErrInvalidPatch, i)
+		}
+		segments, pointerErr := pointerSegments(*operation.Path)
+		if pointerErr != nil {
+			return recordPatch{}, pointerErr
+		}
+		path := strings.Join(segments, ".")
+		last := segments[len(segments)-1]
+		var value interface{}
+		if operation.Value != nil {
+			if unmarshalErr := json.Unmarshal(*operation.Value, &value); unmarshalErr != nil {
+				return recordPatch{}, unmarshalErr
+			}
+		} else if operation.Op == "add" || operation.Op == "replace" || operation.Op == "test" {
+			return recordPatch{}, fmt.Errorf("%w: %s operation %d has no value", ErrInvalidPatch, operation.Op, i)
+		}
+		switch operation.Op {
+		case "add":
+			parent := strings.Join(segments[:len(segments)-1], ".")
+			if last == "-" || isArrayIndex(last) {
+				if len(parent) == 0 {
+					return recordPatch{}, fmt.Errorf("%w: '%s' is not an array", ErrInvalidPatch, *operation.Path)
+				}
+				if pushed, found := patch.push[parent]; found {
+					// Appends to the same array add up, other insertions would conflict
+					each := pushed.(bson.M)
+					if _, positioned := each["$position"]; positioned || last != "-" {
+						return recordPatch{}, fmt.Errorf("%w: '%s' is inserted into more than once", ErrInvalidPatch, parent)
+					}
+					each["$each"] = append(each["$each"].(bson.A), value)
+					continue
+				}
+				each := bson.M{"$each": bson.A{value}}
+				if last != "-" {
+					position, _ := strconv.Atoi(last)
+					each["$position"] = position
+				}
+				patch.push[parent] = each
+				written = append(written, parent)
+				continue
+			}
+			patch.setField(path, value)
+			written = append(written, path)
+		case "remove":
+			if isArrayIndex(last) {
+				return recordPatch{}, fmt.Errorf("%w: array elements cannot be removed", ErrInvalidPatch)
+			}
+			if requireErr := require(path, bson.M{"$exists": true}); requireErr != nil {
+				return recordPatch{}, requireErr
+			}
+			patch.unsetField(path)
+			written = append(written, path)
+		case "replace":
+			if requireErr := require(path, bson.M{"$exists": true}); requireErr != nil {
+				return recordPatch{}, requireErr
+			}
+			patch.setField(path, value)
+			written = append(written, path)
+		case "move":
+			if operation.From == nil {
+				return recordPatch{}, fmt.Errorf("%w: move operation %d has no from", ErrInvalidPatch, i)
+			}
+			fromSegments, fromErr := pointerSegments(*operation.From)
+			if fromErr != nil {
+				return recordPatch{}, fromErr
+			}
+			if isArrayIndex(last) || isArrayIndex(fromSegments[len(fromSegments)-1]) {
+				return recordPatch{}, fmt.Errorf("%w: array elements cannot be moved", ErrInvalidPatch)
+			}
+			from := strings.Join(fromSegments, ".")
+			if requireErr := require(from, bson.M{"$exists": true}); requireErr != nil {
+				return recordPatch{}, requireErr
+			}
+			patch.rename[from] = path
+			written = append(written, from, path)
+		case "test":
+			// Records are stored from maps, so the order of the fields of a stored object
+			// is not known and an object, or an array that may hold one, would only match
+			// by chance
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				return recordPatch{}, fmt.Errorf("%w: test operation %d compares an object or an array", ErrInvalidPatch, i)
+			}
+			if requireErr := require(path, bson.M{"$eq": value}); requireErr != nil {
+				return recordPatch{}, requireErr
+			}
+		default:
+			return recordPatch{}, fmt.Errorf("%w: operation '%s' not supported", ErrInvalidPatch, operation.Op)
+		}
+	}
+	return patch, nil
+}
+
+// pointerSegments splits a JSON pointer (RFC 6901) into field names. The root of the
+// record cannot be patched.
+func pointerSegments(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") || len(pointer) == 1 {
+		return nil, fmt.Errorf("%w: invalid path '%s'", ErrInvalidPatch, pointer)
+	}
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		if !isPatchField(segment, i == 0) {
+			return nil, fmt.Errorf("%w: field '%s' cannot be patched", ErrInvalidPatch, pointer)
+		}
+		segments[i] = segment
+	}
+	return segments, nil
+}
+
+// isPatchField rejects the fields that would escape their path, and at the top level
+// _id and the metadata, which belong to the operator.
+func isPatchField(name string, topLevel bool) bool {
+	if len(name) == 0 || strings.Contains(name, ".") || strings.HasPrefix(name, "$") {
+		return false
+	}
+	return !topLevel || (name != "_id" && name != metadataField)
+}
+
+// overlaps tells whether two dotted paths are the same field or one is within the
+// other.
+func overlaps(path string, other string) bool {
+	return path == other || strings.HasPrefix(path, other+".") || strings.HasPrefix(other, path+".")
+}
+
+func isArrayIndex(segment string) bool {
+	if len(segment) == 0 || (len(segment) > 1 && segment[0] == '0') {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
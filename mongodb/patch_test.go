@@ -0,0 +1,136 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseMergePatch(t *testing.T) {
+	Convey("Check a merge patch sets, unsets and merges nested fields", t, func() {
+		patch, err := parsePatch(OperationMerge, `{"name":"new","old":null,"address":{"city":"Madrid","zip":null},"tags":["a"]}`)
+		So(err, ShouldBeNil)
+		So(patch.update(), ShouldResemble, bson.M{
+			"$set":   bson.M{"name": "new", "address.city": "Madrid", "tags": []interface{}{"a"}},
+			"$unset": bson.M{"old": "", "address.zip": ""},
+		})
+		So(patch.conditions, ShouldBeEmpty)
+	})
+
+	Convey("Check an empty object in a merge patch sets an empty object", t, func() {
+		patch, err := parsePatch(OperationMerge, `{"address":{},"extra":{"tags":{}}}`)
+		So(err, ShouldBeNil)
+		So(patch.update(), ShouldResemble, bson.M{"$set": bson.M{"address": bson.M{}, "extra.tags": bson.M{}}})
+	})
+
+	Convey("Check a merge patch must be an object", t, func() {
+		_, err := parsePatch(OperationMerge, `["name"]`)
+		So(errors.Is(err, ErrInvalidPatch), ShouldBeTrue)
+	})
+
+	Convey("Check a merge patch cannot reach _id, the metadata or operators", t, func() {
+		for _, content := range []string{`{"_id":"1"}`, `{"_metadata":null}`, `{"$where":"1"}`, `{"a.b":1}`} {
+			_, err := parsePatch(OperationMerge, content)
+			So(errors.Is(err, ErrInvalidPatch), ShouldBeTrue)
+		}
+	})
+}
+
+func TestParseJSONPatch(t *testing.T) {
+	Convey("Check patch operations become update operators and tests become conditions", t, func() {
+		patch, err := parsePatch(OperationPatch, `[
+			{"op":"test","path":"/version","value":3},
+			{"op":"replace","path":"/name","value":"new"},
+			{"op":"add","path":"/address/city","value":"Madrid"},
+			{"op":"remove","path":"/old"},
+			{"op":"add","path":"/tags/-","value":"a"},
+			{"op":"add","path":"/tags/-","value":"b"},
+			{"op":"add","path":"/items/0","value":"first"},
+			{"op":"move","from":"/a~1b","path":"/c"}
+		]`)
+		So(err, ShouldBeNil)
+		So(patch.filter(testTarget), ShouldResemble, bson.M{
+			"_id":     testTarget.id,
+			"version": bson.M{"$eq": float64(3)},
+			"name":    bson.M{"$exists": true},
+			"old":     bson.M{"$exists": true},
+			"a/b":     bson.M{"$exists": true},
+		})
+		So(patch.update(), ShouldResemble, bson.M{
+			"$set":   bson.M{"name": "new", "address.city": "Madrid"},
+			"$unset": bson.M{"old": ""},
+			"$push": bson.M{
+				"tags":  bson.M{"$each": bson.A{"a", "b"}},
+				"items": bson.M{"$each": bson.A{"first"}, "$position": 0},
+			},
+			"$rename": bson.M{"a/b": "c"},
+		})
+	})
+
+	Convey("Check objects and arrays cannot be tested against a record stored in another field order", t, func() {
+		// The record holds {"city":"Madrid","zip":"28001"}, the test lists zip first
+		for _, content := range []string{
+			`[{"op":"test","path":"/address","value":{"zip":"28001","city":"Madrid"}}]`,
+			`[{"op":"test","path":"/geo","value":[{"lon":3,"lat":40}]}]`,
+		} {
+			_, err := parsePatch(OperationPatch, content)
+			So(errors.Is(err, ErrInvalidPatch), ShouldBeTrue)
+		}
+		patch, err := parsePatch(OperationPatch, `[{"op":"test","path":"/address/zip","value":"28001"},{"op":"test","path":"/address/city","value":"Madrid"}]`)
+		So(err, ShouldBeNil)
+		So(patch.conditions, ShouldResemble, bson.M{
+			"address.zip":  bson.M{"$eq": "28001"},
+			"address.city": bson.M{"$eq": "Madrid"},
+		})
+	})
+
+	Convey("Check operations MongoDB cannot express are rejected", t, func() {
+		for _, content := range []string{
+			`[{"op":"copy","from":"/a","path":"/b"}]`,
+			`[{"op":"remove","path":"/tags/0"}]`,
+			`[{"op":"add","path":"/","value":{}}]`,
+			`[{"op":"replace","path":"/_id","value":"1"}]`,
+			`[{"op":"add","path":"/name"}]`,
+			`[{"op":"add","path":"/tags/0","value":"a"},{"op":"add","path":"/tags/-","value":"b"}]`,
+		} {
+			_, err := parsePatch(OperationPatch, content)
+			So(errors.Is(err, ErrInvalidPatch), ShouldBeTrue)
+		}
+	})
+
+	Convey("Check a patch cannot change a field and a field within it", t, func() {
+		for _, content := range []string{
+			`[{"op":"add","path":"/address","value":{}},{"op":"add","path":"/address/city","value":"Madrid"}]`,
+			`[{"op":"remove","path":"/address/zip"},{"op":"replace","path":"/address","value":{}}]`,
+			`[{"op":"add","path":"/tags/-","value":"a"},{"op":"remove","path":"/tags"}]`,
+			`[{"op":"move","from":"/a","path":"/b"},{"op":"add","path":"/b/c","value":1}]`,
+			`[{"op":"move","from":"/a","path":"/a"}]`,
+		} {
+			_, err := parsePatch(OperationPatch, content)
+			So(errors.Is(err, ErrInvalidPatch), ShouldBeTrue)
+		}
+		_, err := parsePatch(OperationPatch, `[{"op":"add","path":"/a","value":1},{"op":"add","path":"/ab","value":1},{"op":"add","path":"/a-b","value":1}]`)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Check a field cannot be tested after the patch changes it", t, func() {
+		for _, content := range []string{
+			`[{"op":"replace","path":"/version","value":4},{"op":"test","path":"/version","value":4}]`,
+			`[{"op":"add","path":"/address","value":{"city":"Madrid"}},{"op":"test","path":"/address/city","value":"Madrid"}]`,
+			`[{"op":"add","path":"/tags/-","value":"a"},{"op":"test","path":"/tags/0","value":"a"}]`,
+			`[{"op":"move","from":"/a","path":"/b"},{"op":"test","path":"/a","value":1}]`,
+		} {
+			_, err := parsePatch(OperationPatch, content)
+			So(errors.Is(err, ErrInvalidPatch), ShouldBeTrue)
+		}
+		_, err := parsePatch(OperationPatch, `[{"op":"test","path":"/version","value":3},{"op":"replace","path":"/version","value":4}]`)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Check a malformed patch is reported", t, func() {
+		_, err := parsePatch(OperationPatch, `{"op":"add"}`)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,187 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrInvalidFilter is returned for filter expressions that cannot be parsed
+var ErrInvalidFilter = errors.New("invalid filter expression")
+
+// comparisonOperators maps the operators of a filter expression to MongoDB operators
+var comparisonOperators = map[string]string{
+	"=":  "$eq",
+	"==": "$eq",
+	"!=": "$ne",
+	">":  "$gt",
+	">=": "$gte",
+	"<":  "$lt",
+	"<=": "$lte",
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// parseFilter turns a filter expression into a MongoDB filter. An expression is a
+// list of conditions `field op value` joined by `and` and `or`, where `and` binds
+// tighter than `or`. Fields may use dots to reach nested values, values are quoted
+// strings, numbers, true, false or null. An empty expression matches every record.
+func parseFilter(expression string) (bson.M, error) {
+	tokens, tokenErr := tokenize(expression)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+	if len(tokens) == 0 {
+		return bson.M{}, nil
+	}
+	var alternatives []bson.M
+	var conditions []bson.M
+	for i := 0; ; i += 4 {
+		if i+3 > len(tokens) {
+			return nil, fmt.Errorf("%w: incomplete condition at the end", ErrInvalidFilter)
+		}
+		condition, conditionErr := parseCondition(tokens[i], tokens[i+1], tokens[i+2])
+		if conditionErr != nil {
+			return nil, conditionErr
+		}
+		conditions = append(conditions, condition)
+		if i+3 == len(tokens) {
+			alternatives = append(alternatives, joinConditions("$and", conditions))
+			break
+		}
+		switch connective := tokens[i+3]; {
+		case connective.kind == tokenWord && strings.EqualFold(connective.value, "and"):
+		case connective.kind == tokenWord && strings.EqualFold(connective.value, "or"):
+			alternatives = append(alternatives, joinConditions("$and", conditions))
+			conditions = nil
+		default:
+			return nil, fmt.Errorf("%w: expected and/or, found '%s'", ErrInvalidFilter, connective.value)
+		}
+	}
+	return joinConditions("$or", alternatives), nil
+}
+
+func joinConditions(operator string, conditions []bson.M) bson.M {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	joined := make(bson.A, len(conditions))
+	for i, condition := range conditions {
+		joined[i] = condition
+	}
+	return bson.M{operator: joined}
+}
+
+func parseCondition(field token, operator token, value token) (bson.M, error) {
+	if field.kind != tokenWord || !isFieldName(field.value) {
+		return nil, fmt.Errorf("%w: invalid field '%s'", ErrInvalidFilter, field.value)
+	}
+	mongoOperator, found := comparisonOperators[operator.value]
+	if operator.kind != tokenOperator || !found {
+		return nil, fmt.Errorf("%w: invalid operator '%s'", ErrInvalidFilter, operator.value)
+	}
+	parsedValue, valueErr := parseValue(value)
+	if valueErr != nil {
+		return nil, valueErr
+	}
+	return bson.M{field.value: bson.M{mongoOperator: parsedValue}}, nil
+}
+
+// isFieldName accepts letters, digits, underscores and dots, so that expressions
+// cannot reach MongoDB operators.
+func isFieldName(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, "..") {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseValue(value token) (interface{}, error) {
+	if value.kind == tokenString {
+		return value.value, nil
+	}
+	if value.kind != tokenWord {
+		return nil, fmt.Errorf("%w: invalid value '%s'", ErrInvalidFilter, value.value)
+	}
+	switch value.value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if integer, intErr := strconv.ParseInt(value.value, 10, 64); intErr == nil {
+		return integer, nil
+	}
+	if float, floatErr := strconv.ParseFloat(value.value, 64); floatErr == nil {
+		return float, nil
+	}
+	return nil, fmt.Errorf("%w: invalid value '%s', strings must be quoted", ErrInvalidFilter, value.value)
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var text strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					text.WriteRune(runes[i])
+					continue
+				}
+				if runes[i] == r {
+					closed = true
+					i++
+					break
+				}
+				text.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated string", ErrInvalidFilter)
+			}
+			tokens = append(tokens, token{tokenString, text.String()})
+		case strings.ContainsRune("=!<>", r):
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				operator += "="
+			}
+			i += len(operator)
+			tokens = append(tokens, token{tokenOperator, operator})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("=!<>\"'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,60 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseFilter(t *testing.T) {
+	Convey("Check an empty expression matches every record ", t, func() {
+		filter, err := parseFilter("  ")
+		So(err, ShouldBeNil)
+		So(filter, ShouldResemble, bson.M{})
+	})
+
+	Convey("Check a single condition ", t, func() {
+		filter, err := parseFilter(`status = "active"`)
+		So(err, ShouldBeNil)
+		So(filter, ShouldResemble, bson.M{"status": bson.M{"$eq": "active"}})
+	})
+
+	Convey("Check values are typed ", t, func() {
+		filter, err := parseFilter(`age >= 18 and score < 2.5 and enabled != false and owner = null and name = 'O\'Neil'`)
+		So(err, ShouldBeNil)
+		So(filter, ShouldResemble, bson.M{"$and": bson.A{
+			bson.M{"age": bson.M{"$gte": int64(18)}},
+			bson.M{"score": bson.M{"$lt": 2.5}},
+			bson.M{"enabled": bson.M{"$ne": false}},
+			bson.M{"owner": bson.M{"$eq": nil}},
+			bson.M{"name": bson.M{"$eq": "O'Neil"}},
+		}})
+	})
+
+	Convey("Check and binds tighter than or ", t, func() {
+		filter, err := parseFilter(`a = 1 and b = 2 OR address.city = "Madrid"`)
+		So(err, ShouldBeNil)
+		So(filter, ShouldResemble, bson.M{"$or": bson.A{
+			bson.M{"$and": bson.A{bson.M{"a": bson.M{"$eq": int64(1)}}, bson.M{"b": bson.M{"$eq": int64(2)}}}},
+			bson.M{"address.city": bson.M{"$eq": "Madrid"}},
+		}})
+	})
+
+	Convey("Check invalid expressions are rejected ", t, func() {
+		for _, expression := range []string{
+			`status`,
+			`status =`,
+			`status = active`,
+			`$where = "1"`,
+			`status ! "active"`,
+			`status = "active" nor a = 1`,
+			`status = "active`,
+			`a.. = 1`,
+		} {
+			_, err := parseFilter(expression)
+			So(errors.Is(err, ErrInvalidFilter), ShouldBeTrue)
+		}
+	})
+}
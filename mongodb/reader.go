@@ -0,0 +1,232 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrMissingDatabase is returned for reads without a DB name
+var ErrMissingDatabase = errors.New("DB name not provided")
+
+// ErrInvalidPageToken is returned for page tokens not issued by a previous read
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Record is a record read from the RDB.
+type Record struct {
+	ID       string // _id of the record, in text form
+	DBName   string
+	Group    string
+	Content  string          // JSON document of the record, without _id and metadata
+	Metadata *RecordMetadata // nil for records written without metadata
+}
+
+// RecordPage is a page of records ordered by _id. NextPageToken is empty on the last
+// page.
+type RecordPage struct {
+	Records       []Record
+	NextPageToken string
+}
+
+// GetRecord reads a record from the location its events are written to. It returns
+// ErrRecordNotFound if there is no such record.
+func GetRecord(ctx context.Context, dbName string, group string, id string) (Record, error) {
+	methodMsg := "GetRecord"
+	if len(dbName) == 0 {
+		return Record{}, ErrMissingDatabase
+	}
+	target, targetErr := resolveTarget(dbName, group, id)
+	if targetErr != nil {
+		return Record{}, targetErr
+	}
+	rdbClient, err := getRDBClient()
+	if err != nil {
+		return Record{}, err
+	}
+	var document bson.D
+	err = withRetry(ctx, methodMsg, func() error {
+		readCtx, cancel := boundedContext(ctx)
+		defer cancel()
+		defer observeDuration("find", time.Now())
+		return target.collection(rdbClient).FindOne(readCtx, target.filter()).Decode(&document)
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Record{}, ErrRecordNotFound
+	}
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, fmt.Sprintf("Error reading record with ID '%s' - Database '%s' - Collection '%s'", target.rawID, target.dbName, target.colName))
+		return Record{}, err
+	}
+	return toRecord(target.dbName, target.colName, document)
+}
+
+// ListRecords reads a page of the records of a group.
+func ListRecords(ctx context.Context, dbName string, group string, pageSize int, pageToken string) (RecordPage, error) {
+	return findRecords(ctx, "ListRecords", dbName, group, bson.M{}, pageSize, pageToken)
+}
+
+// QueryRecords reads a page of the records of a group that match a filter expression,
+// see parseFilter for its syntax.
+func QueryRecords(ctx context.Context, dbName string, group string, expression string, pageSize int, pageToken string) (RecordPage, error) {
+	filter, filterErr := parseFilter(expression)
+	if filterErr != nil {
+		return RecordPage{}, filterErr
+	}
+	return findRecords(ctx, "QueryRecords", dbName, group, filter, pageSize, pageToken)
+}
+
+func findRecords(ctx context.Context, methodMsg string, dbName string, group string, filter bson.M, pageSize int, pageToken string) (RecordPage, error) {
+	if len(dbName) == 0 {
+		return RecordPage{}, ErrMissingDatabase
+	}
+	if len(pageToken) > 0 {
+		lastID, tokenErr := decodePageToken(pageToken)
+		if tokenErr != nil {
+			return RecordPage{}, tokenErr
+		}
+		filter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": lastID}}}}
+	}
+	rdbClient, err := getRDBClient()
+	if err != nil {
+		return RecordPage{}, err
+	}
+	dbName = databaseName(dbName)
+	colName := collectionName(group)
+	size := pageSizeOrDefault(pageSize)
+	// One more than requested to know whether there is a next page
+	findOptions := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(size + 1))
+	var documents []bson.D
+	err = withRetry(ctx, methodMsg, func() error {
+		readCtx, cancel := boundedContext(ctx)
+		defer cancel()
+		defer observeDuration("find", time.Now())
+		cursor, findErr := rdbClient.Database(dbName).Collection(colName).Find(readCtx, filter, findOptions)
+		if findErr != nil {
+			return findErr
+		}
+		documents = nil
+		return cursor.All(readCtx, &documents)
+	})
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, fmt.Sprintf("Error reading records - Database '%s' - Collection '%s'", dbName, colName))
+		return RecordPage{}, err
+	}
+
+	page := RecordPage{}
+	if len(documents) > size {
+		documents = documents[:size]
+		nextToken, tokenErr := encodePageToken(documentID(documents[size-1]))
+		if tokenErr != nil {
+			return RecordPage{}, tokenErr
+		}
+		page.NextPageToken = nextToken
+	}
+	for _, document := range documents {
+		record, recordErr := toRecord(dbName, colName, document)
+		if recordErr != nil {
+			return RecordPage{}, recordErr
+		}
+		page.Records = append(page.Records, record)
+	}
+	return page, nil
+}
+
+// pageSizeOrDefault applies Rdb.Pagesize to unset page sizes and caps them to
+// Rdb.Maxpagesize.
+func pageSizeOrDefault(pageSize int) int {
+	if pageSize <= 0 {
+		pageSize = config.Rdb.Pagesize
+	}
+	if config.Rdb.Maxpagesize > 0 && pageSize > config.Rdb.Maxpagesize {
+		pageSize = config.Rdb.Maxpagesize
+	}
+	if pageSize <= 0 {
+		pageSize = 1
+	}
+	return pageSize
+}
+
+// encodePageToken keeps the _id of the last record of a page, whatever its type, so
+// that the next page starts right after it.
+func encodePageToken(lastID interface{}) (string, error) {
+	raw, marshalErr := bson.Marshal(bson.M{"_id": lastID})
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodePageToken(pageToken string) (bson.RawValue, error) {
+	raw, decodeErr := base64.RawURLEncoding.DecodeString(pageToken)
+	if decodeErr != nil {
+		return bson.RawValue{}, ErrInvalidPageToken
+	}
+	lastID, lookupErr := bson.Raw(raw).LookupErr("_id")
+	if lookupErr != nil {
+		return bson.RawValue{}, ErrInvalidPageToken
+	}
+	return lastID, nil
+}
+
+func documentID(document bson.D) interface{} {
+	for _, element := range document {
+		if element.Key == "_id" {
+			return element.Value
+		}
+	}
+	return nil
+}
+
+func toRecord(dbName string, colName string, document bson.D) (Record, error) {
+	record := Record{
+		ID:     idText(documentID(document)),
+		DBName: dbName,
+		Group:  colName,
+	}
+	content := make(bson.D, 0, len(document))
+	for _, element := range document {
+		switch element.Key {
+		case "_id":
+		case metadataField:
+			metadata, metadataErr := decodeMetadata(element.Value)
+			if metadataErr != nil {
+				return Record{}, metadataErr
+			}
+			record.Metadata = metadata
+		default:
+			content = append(content, element)
+		}
+	}
+	contentJSON, marshalErr := bson.MarshalExtJSON(content, false, false)
+	if marshalErr != nil {
+		return Record{}, marshalErr
+	}
+	record.Content = string(contentJSON)
+	return record, nil
+}
+
+// idText renders the _id values written by each ID mode as text.
+func idText(id interface{}) string {
+	switch value := id.(type) {
+	case primitive.ObjectID:
+		return value.Hex()
+	case string:
+		return value
+	case primitive.Binary:
+		if parsed, parseErr := uuid.FromBytes(value.Data); parseErr == nil && value.Subtype == 0x04 {
+			return parsed.String()
+		}
+		return base64.StdEncoding.EncodeToString(value.Data)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
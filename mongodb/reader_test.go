@@ -0,0 +1,67 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"github.com/google/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPageToken(t *testing.T) {
+	Convey("Check a page token keeps the type of the last ID ", t, func() {
+		oid := primitive.NewObjectID()
+		token, err := encodePageToken(oid)
+		So(err, ShouldBeNil)
+		lastID, err := decodePageToken(token)
+		So(err, ShouldBeNil)
+		So(lastID.ObjectID(), ShouldEqual, oid)
+	})
+
+	Convey("Check tampered page tokens are rejected ", t, func() {
+		_, err := decodePageToken("not a token")
+		So(errors.Is(err, ErrInvalidPageToken), ShouldBeTrue)
+	})
+}
+
+func TestToRecord(t *testing.T) {
+	Convey("Check a document is read back without its _id ", t, func() {
+		id := uuid.New()
+		document := bson.D{{Key: "_id", Value: primitive.Binary{Subtype: 0x04, Data: id[:]}}, {Key: "name", Value: "A"}, {Key: "age", Value: int32(3)}}
+		record, err := toRecord("TestRepository", "main", document)
+		So(err, ShouldBeNil)
+		So(record.ID, ShouldEqual, id.String())
+		So(record.Content, ShouldEqual, `{"name":"A","age":3}`)
+		So(record.Metadata, ShouldBeNil)
+	})
+
+	Convey("Check the metadata is read apart from the content ", t, func() {
+		metadata := eventMetadata(utils.RecordEvent{OperationType: "update", User: "me@xqledger.com", ProcessingTime: 42}, "abc", time.Unix(50, 0))
+		document := bson.D{{Key: "_id", Value: "record.json"}, {Key: "name", Value: "A"}, {Key: metadataField, Value: metadata}}
+		raw, _ := bson.Marshal(document)
+		var stored bson.D
+		So(bson.Unmarshal(raw, &stored), ShouldBeNil)
+		record, err := toRecord("TestRepository", "main", stored)
+		So(err, ShouldBeNil)
+		So(record.Content, ShouldEqual, `{"name":"A"}`)
+		So(*record.Metadata, ShouldResemble, metadata)
+	})
+
+	Convey("Check IDs of every ID mode are rendered as text ", t, func() {
+		oid := primitive.NewObjectID()
+		So(idText(oid), ShouldEqual, oid.Hex())
+		So(idText("record.json"), ShouldEqual, "record.json")
+	})
+}
+
+func TestPageSizeOrDefault(t *testing.T) {
+	Convey("Check page sizes default and are capped ", t, func() {
+		So(pageSizeOrDefault(0), ShouldEqual, config.Rdb.Pagesize)
+		So(pageSizeOrDefault(5), ShouldEqual, 5)
+		So(pageSizeOrDefault(config.Rdb.Maxpagesize+1), ShouldEqual, config.Rdb.Maxpagesize)
+	})
+}
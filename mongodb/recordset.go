@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	utils "xqledger/rdboperator/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrTransactionsNotSupported is returned for record sets sent to a server without
+// transactions, which could only apply them in part
+var ErrTransactionsNotSupported = errors.New("RDB does not support transactions")
+
+// handleSetEvent applies one event of a record set within its transaction.
+var handleSetEvent = HandleEventContext
+
+// HandleRecordSetContext applies the events of a record set in order and as a whole.
+// On replica sets and sharded clusters they are written in one multi-document
+// transaction, so either all of them are visible or none is. A standalone server has
+// no transactions, so the record set is rejected there without applying any event.
+// Every event given is applied, so events that are not to be applied must be left out
+// by the caller.
+func HandleRecordSetContext(ctx context.Context, events []utils.RecordEvent) error {
+	methodMsg := "HandleRecordSet"
+	if len(events) == 0 {
+		return nil
+	}
+	rdbClient, err := getRDBClient()
+	if err != nil {
+		utils.PrintLogErrorContext(ctx, err, componentMessage, methodMsg, utils.Error_unmarshalling_RDB)
+		return err
+	}
+	transactions, topologyErr := supportsTransactions(ctx, rdbClient)
+	if topologyErr != nil {
+		utils.PrintLogErrorContext(ctx, topologyErr, componentMessage, methodMsg, "Error reading RDB topology")
+		return topologyErr
+	}
+	if !transactions {
+		utils.PrintLogErrorContext(ctx, ErrTransactionsNotSupported, componentMessage, methodMsg, utils.Recordset_not_atomic)
+		return ErrTransactionsNotSupported
+	}
+	session, sessionErr := rdbClient.StartSession()
+	if sessionErr != nil {
+		utils.PrintLogErrorContext(ctx, sessionErr, componentMessage, methodMsg, utils.Error_recordset_RDB)
+		return sessionErr
+	}
+	defer session.EndSession(context.Background())
+	_, txnErr := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, applyRecordSet(sessionCtx, events)
+	})
+	if txnErr != nil {
+		utils.PrintLogErrorContext(ctx, txnErr, componentMessage, methodMsg, utils.Error_recordset_RDB)
+		return txnErr
+	}
+	utils.PrintLogInfoContext(ctx, componentMessage, methodMsg, fmt.Sprintf(utils.Successful_recordset, len(events)))
+	return nil
+}
+
+// applyRecordSet applies the events in order and stops at the first failure, which is
+// returned with the position of the event. The error aborts the transaction, which
+// rolls back the events applied before it.
+func applyRecordSet(ctx context.Context, events []utils.RecordEvent) error {
+	for i, event := range events {
+		if err := handleSetEvent(ctx, event); err != nil {
+			return fmt.Errorf("event %d of %d, ID '%s': %w", i+1, len(events), event.Id, err)
+		}
+	}
+	return nil
+}
+
+// supportsTransactions tells replica set members and mongos routers, which run
+// multi-document transactions, from standalone servers.
+func supportsTransactions(ctx context.Context, client *mongo.Client) (bool, error) {
+	commandCtx, cancel := boundedContext(ctx)
+	defer cancel()
+	var topology struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(commandCtx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&topology)
+	if err != nil {
+		return false, err
+	}
+	return isTransactional(topology.SetName, topology.Msg), nil
+}
+
+func isTransactional(setName string, msg string) bool {
+	return len(setName) > 0 || msg == "isdbgrid"
+}
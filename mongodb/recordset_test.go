@@ -0,0 +1,47 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsTransactional(t *testing.T) {
+	Convey("Check replica sets and mongos run transactions and standalone servers do not", t, func() {
+		So(isTransactional("rs0", ""), ShouldBeTrue)
+		So(isTransactional("", "isdbgrid"), ShouldBeTrue)
+		So(isTransactional("", ""), ShouldBeFalse)
+	})
+}
+
+func TestApplyRecordSet(t *testing.T) {
+	original := handleSetEvent
+	defer func() { handleSetEvent = original }()
+	writeErr := errors.New("duplicate key")
+	var applied []string
+	handleSetEvent = func(ctx context.Context, event utils.RecordEvent) error {
+		if event.Id == "2" {
+			return writeErr
+		}
+		applied = append(applied, event.Id)
+		return nil
+	}
+
+	Convey("Check a failing write stops the record set and fails its transaction", t, func() {
+		applied = nil
+		err := applyRecordSet(context.Background(), []utils.RecordEvent{{Id: "1"}, {Id: "2"}, {Id: "3"}})
+		So(errors.Is(err, writeErr), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "event 2 of 3, ID '2'")
+		So(applied, ShouldResemble, []string{"1"})
+	})
+
+	Convey("Check every event of a record set is applied in order", t, func() {
+		applied = nil
+		err := applyRecordSet(context.Background(), []utils.RecordEvent{{Id: "1"}, {Id: "3"}})
+		So(err, ShouldBeNil)
+		So(applied, ShouldResemble, []string{"1", "3"})
+	})
+}
@@ -0,0 +1,136 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+	utils "xqledger/rdboperator/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// Server error codes that are worth retrying: elections, shutdowns, unreachable
+// hosts and time limits
+var transientErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	50,    // MaxTimeMSExpired
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	112,   // WriteConflict
+	189,   // PrimarySteppedDown
+	262,   // ExceededTimeLimit
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+var transientErrorLabels = []string{
+	"NetworkError",
+	"RetryableWriteError",
+	"TransientTransactionError",
+}
+
+// IsTransientError tells whether err is worth retrying. Network failures, primary
+// elections, timeouts and connection pool exhaustion are transient; anything else,
+// like a duplicate key or a malformed record, is permanent.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	var waitQueueErr topology.WaitQueueTimeoutError
+	if errors.As(err, &waitQueueErr) {
+		return true
+	}
+	var selectionErr topology.ServerSelectionError
+	if errors.As(err, &selectionErr) || errors.Is(err, topology.ErrServerSelectionTimeout) {
+		return true
+	}
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		for _, label := range transientErrorLabels {
+			if serverErr.HasErrorLabel(label) {
+				return true
+			}
+		}
+		for _, code := range transientErrorCodes {
+			if serverErr.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BackoffDelay returns the wait after the given failed attempt: the base delay doubled
+// on every attempt and capped at max, of which a random half is kept as jitter.
+func BackoffDelay(attempt int, base time.Duration, max time.Duration) time.Duration {
+	if max < base {
+		max = base
+	}
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+type withoutRetryKey struct{}
+
+// WithoutRetry returns a copy of ctx whose operations are attempted once, for callers
+// that retry transient errors themselves, so that attempts do not multiply.
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutRetryKey{}, true)
+}
+
+// withRetry runs the operation up to Rdb.Maxattempts times while it fails with a
+// transient error, waiting between attempts with exponential backoff from
+// Rdb.Retrybasedelay up to Rdb.Retrymaxdelay milliseconds, or until ctx is done.
+// The operation runs once under WithoutRetry, and inside a transaction: a transient
+// error aborts the transaction, which is retried as a whole by the session.
+func withRetry(ctx context.Context, methodMsg string, operation func() error) error {
+	attempts := config.Rdb.Maxattempts
+	if withoutRetry, _ := ctx.Value(withoutRetryKey{}).(bool); attempts < 1 || withoutRetry || mongo.SessionFromContext(ctx) != nil {
+		attempts = 1
+	}
+	base := time.Duration(config.Rdb.Retrybasedelay) * time.Millisecond
+	max := time.Duration(config.Rdb.Retrymaxdelay) * time.Millisecond
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = operation()
+		if err == nil || !IsTransientError(err) {
+			return err
+		}
+		if attempt < attempts {
+			delay := BackoffDelay(attempt, base, max)
+			utils.PrintLogWarnContext(ctx, err, componentMessage, methodMsg, fmt.Sprintf("%s - Attempt %d of %d - Retrying in %v", utils.Error_transient_RDB, attempt, attempts, delay))
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func TestIsTransientError(t *testing.T) {
+	Convey("Check elections, timeouts and pool exhaustion are transient", t, func() {
+		So(IsTransientError(mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}), ShouldBeTrue)
+		So(IsTransientError(mongo.CommandError{Labels: []string{"RetryableWriteError"}}), ShouldBeTrue)
+		So(IsTransientError(context.DeadlineExceeded), ShouldBeTrue)
+		So(IsTransientError(topology.WaitQueueTimeoutError{}), ShouldBeTrue)
+		So(IsTransientError(topology.ServerSelectionError{Wrapped: topology.ErrServerSelectionTimeout}), ShouldBeTrue)
+	})
+
+	Convey("Check duplicate keys and unknown errors are permanent", t, func() {
+		duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "E11000 duplicate key error"}}}
+		So(IsTransientError(duplicate), ShouldBeFalse)
+		So(IsTransientError(errors.New("ID not provided")), ShouldBeFalse)
+		So(IsTransientError(nil), ShouldBeFalse)
+	})
+}
+
+func TestBackoffDelay(t *testing.T) {
+	Convey("Check backoff grows exponentially with jitter and is capped", t, func() {
+		base := 100 * time.Millisecond
+		max := time.Second
+		So(BackoffDelay(1, base, max), ShouldBeBetweenOrEqual, 50*time.Millisecond, 100*time.Millisecond)
+		So(BackoffDelay(3, base, max), ShouldBeBetweenOrEqual, 200*time.Millisecond, 400*time.Millisecond)
+		So(BackoffDelay(10, base, max), ShouldBeBetweenOrEqual, 500*time.Millisecond, time.Second)
+	})
+}
+
+func TestWithRetry(t *testing.T) {
+	config.Rdb.Maxattempts = 3
+	config.Rdb.Retrybasedelay = 1
+	config.Rdb.Retrymaxdelay = 2
+
+	Convey("Check transient errors are retried until success", t, func() {
+		calls := 0
+		err := withRetry(context.Background(), "test", func() error {
+			calls++
+			if calls < 3 {
+				return mongo.CommandError{Code: 11602, Name: "InterruptedDueToReplStateChange"}
+			}
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(calls, ShouldEqual, 3)
+	})
+
+	Convey("Check permanent errors are returned on the first attempt", t, func() {
+		calls := 0
+		err := withRetry(context.Background(), "test", func() error {
+			calls++
+			return errors.New("fake permanent error")
+		})
+		So(err, ShouldNotBeNil)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Check operations inside a transaction are not retried on their own", t, func() {
+		calls := 0
+		ctx := mongo.NewSessionContext(context.Background(), fakeSession{})
+		err := withRetry(ctx, "test", func() error {
+			calls++
+			return mongo.CommandError{Code: 112, Name: "WriteConflict"}
+		})
+		So(IsTransientError(err), ShouldBeTrue)
+		So(calls, ShouldEqual, 1)
+	})
+}
+
+func TestWithoutRetry(t *testing.T) {
+	config.Rdb.Maxattempts = 3
+	config.Rdb.Retrybasedelay = 1
+	config.Rdb.Retrymaxdelay = 2
+
+	Convey("Check operations are attempted once for callers that retry themselves", t, func() {
+		calls := 0
+		err := withRetry(WithoutRetry(context.Background()), "test", func() error {
+			calls++
+			return mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+		})
+		So(IsTransientError(err), ShouldBeTrue)
+		So(calls, ShouldEqual, 1)
+	})
+
+	Convey("Check the wait between attempts ends with the context", t, func() {
+		config.Rdb.Retrybasedelay = 60000
+		config.Rdb.Retrymaxdelay = 60000
+		defer func() { config.Rdb.Retrybasedelay, config.Rdb.Retrymaxdelay = 1, 2 }()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		calls := 0
+		err := withRetry(ctx, "test", func() error {
+			calls++
+			return mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}
+		})
+		So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		So(calls, ShouldEqual, 1)
+	})
+}
+
+// fakeSession stands for the session of a running transaction
+type fakeSession struct {
+	mongo.Session
+}
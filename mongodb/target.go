@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultCollection = "main"
+
+// ErrMissingID is returned for events without a record ID
+var ErrMissingID = errors.New("ID not provided")
+
+// recordTarget is the location of a record in the RDB. Every operation resolves it
+// the same way so that inserts, updates and deletes always agree on it.
+type recordTarget struct {
+	dbName  string      // database name, sanitised
+	colName string      // collection name, the Git group
+	rawID   string      // record ID as received in the event
+	id      interface{} // value stored in _id
+}
+
+// RecordLocation returns the database and collection that the events of a DB name and
+// group are written to.
+func RecordLocation(dbName string, group string) (string, string) {
+	return databaseName(dbName), collectionName(group)
+}
+
+// resolveTarget maps the DB name, group and ID of an event to the database,
+// collection and _id of the record, following the ID strategy in Rdb.Idmode.
+func resolveTarget(dbName string, group string, rawID string) (recordTarget, error) {
+	target := recordTarget{
+		dbName:  databaseName(dbName),
+		colName: collectionName(group),
+		rawID:   rawID,
+	}
+	if len(rawID) == 0 {
+		return target, ErrMissingID
+	}
+	id, idErr := recordID(idMode(config.Rdb.Idmode), target.dbName, target.colName, rawID)
+	if idErr != nil {
+		return target, idErr
+	}
+	target.id = id
+	return target, nil
+}
+
+// databaseName removes the dots that MongoDB does not accept in database names.
+func databaseName(dbName string) string {
+	return strings.ReplaceAll(dbName, ".", "")
+}
+
+func collectionName(group string) string {
+	if len(group) == 0 {
+		return defaultCollection
+	}
+	return group
+}
+
+func (t recordTarget) collection(client *mongo.Client) *mongo.Collection {
+	return client.Database(t.dbName).Collection(t.colName)
+}
+
+func (t recordTarget) filter() bson.M {
+	return bson.M{"_id": t.id}
+}
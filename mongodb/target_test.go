@@ -0,0 +1,32 @@
+package mongodb
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolveTarget(t *testing.T) {
+	Convey("Check DB name is sanitised and the group is the collection", t, func() {
+		target, err := resolveTarget("Git.Operator.Repo", "browsers", id)
+		So(err, ShouldBeNil)
+		So(target.dbName, ShouldEqual, "GitOperatorRepo")
+		So(target.colName, ShouldEqual, "browsers")
+		oid, _ := primitive.ObjectIDFromHex(id)
+		So(target.filter()["_id"], ShouldEqual, oid)
+	})
+
+	Convey("Check empty group goes to the main collection", t, func() {
+		target, err := resolveTarget(repo, "", id)
+		So(err, ShouldBeNil)
+		So(target.colName, ShouldEqual, defaultCollection)
+	})
+
+	Convey("Check missing and invalid IDs are rejected", t, func() {
+		_, err := resolveTarget(repo, "", "")
+		So(err, ShouldEqual, ErrMissingID)
+		_, err = resolveTarget(repo, "", "not-an-object-id")
+		So(err, ShouldNotBeNil)
+	})
+}
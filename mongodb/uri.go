@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// buildURI returns Rdb.Uri when set. Otherwise the connection string is built from
+// the structured rdb fields: Host is a comma separated list of hosts, and Port is
+// appended to the hosts without one unless Srv is enabled.
+func buildURI() string {
+	rdbConfig := config.Rdb
+	if len(rdbConfig.Uri) > 0 {
+		return rdbConfig.Uri
+	}
+
+	scheme := "mongodb"
+	if rdbConfig.Srv {
+		scheme = "mongodb+srv"
+	}
+	var hosts []string
+	for _, host := range strings.Split(rdbConfig.Host, ",") {
+		host = strings.TrimSpace(host)
+		if len(host) == 0 {
+			continue
+		}
+		if !rdbConfig.Srv && rdbConfig.Port > 0 && !strings.Contains(host, ":") {
+			host = host + ":" + strconv.Itoa(rdbConfig.Port)
+		}
+		hosts = append(hosts, host)
+	}
+
+	query := url.Values{}
+	setIfPresent := func(key string, value string) {
+		if len(value) > 0 {
+			query.Set(key, value)
+		}
+	}
+	setIfPresent("authSource", rdbConfig.Authsource)
+	setIfPresent("authMechanism", rdbConfig.Authmechanism)
+	setIfPresent("replicaSet", rdbConfig.Replicaset)
+	if rdbConfig.Tls {
+		query.Set("tls", "true")
+	}
+	setIfPresent("tlsCAFile", rdbConfig.Tlscafile)
+	setIfPresent("tlsCertificateKeyFile", rdbConfig.Tlscertfile)
+	setIfPresent("readConcernLevel", rdbConfig.Readconcern)
+	setIfPresent("w", rdbConfig.Writeconcern)
+	setIfPresent("appName", rdbConfig.Appname)
+	query.Set("retryWrites", "true")
+
+	uri := url.URL{
+		Scheme:   scheme,
+		Host:     strings.Join(hosts, ","),
+		Path:     "/" + rdbConfig.Database,
+		RawQuery: query.Encode(),
+	}
+	if len(rdbConfig.Username) > 0 {
+		uri.User = url.UserPassword(rdbConfig.Username, rdbConfig.Password)
+	}
+	return uri.String()
+}
@@ -0,0 +1,30 @@
+package mongodb
+
+import (
+	"errors"
+	"strings"
+)
+
+// Write semantics that can be configured per operation in Rdb.Insertmode,
+// Rdb.Updatemode and Rdb.Deletemode
+const (
+	// WriteModeStrict fails when the record is already there on insert, or missing on update
+	WriteModeStrict = "strict"
+	// WriteModeUpsert replaces the record, creating it when missing
+	WriteModeUpsert = "upsert"
+	// WriteModeIdempotent accepts an already applied insert, or an update of a missing record, as a no-op
+	WriteModeIdempotent = "idempotent"
+)
+
+// ErrRecordNotFound is returned when the record an operation targets is not in the RDB
+var ErrRecordNotFound = errors.New("record not found in RDB")
+
+// writeMode normalises a configured mode, falling back to strict.
+func writeMode(configured string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(configured)); mode {
+	case WriteModeUpsert, WriteModeIdempotent:
+		return mode
+	default:
+		return WriteModeStrict
+	}
+}
@@ -0,0 +1,55 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWriteMode(t *testing.T) {
+	Convey("Check configured write modes are normalised", t, func() {
+		So(writeMode("Upsert"), ShouldEqual, WriteModeUpsert)
+		So(writeMode(" idempotent "), ShouldEqual, WriteModeIdempotent)
+		So(writeMode(""), ShouldEqual, WriteModeStrict)
+		So(writeMode("unknown"), ShouldEqual, WriteModeStrict)
+	})
+}
+
+var testTarget = recordTarget{dbName: repo, colName: defaultCollection, rawID: id}
+
+func TestCheckUpdateResult(t *testing.T) {
+	Convey("Check update of a missing record fails in strict mode", t, func() {
+		err := checkUpdateResult(context.Background(), &mongo.UpdateResult{}, WriteModeStrict, "test", testTarget)
+		So(err, ShouldEqual, ErrRecordNotFound)
+	})
+
+	Convey("Check update of a missing record is skipped in idempotent mode", t, func() {
+		err := checkUpdateResult(context.Background(), &mongo.UpdateResult{}, WriteModeIdempotent, "test", testTarget)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Check upserted and unchanged records are successful", t, func() {
+		So(checkUpdateResult(context.Background(), &mongo.UpdateResult{UpsertedCount: 1}, WriteModeUpsert, "test", testTarget), ShouldBeNil)
+		So(checkUpdateResult(context.Background(), &mongo.UpdateResult{MatchedCount: 1}, WriteModeStrict, "test", testTarget), ShouldBeNil)
+		So(checkUpdateResult(context.Background(), &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, WriteModeStrict, "test", testTarget), ShouldBeNil)
+	})
+}
+
+func TestCheckDeleteResult(t *testing.T) {
+	Convey("Check delete of a missing record fails in strict mode", t, func() {
+		err := checkDeleteResult(context.Background(), &mongo.DeleteResult{}, WriteModeStrict, "test", testTarget)
+		So(err, ShouldEqual, ErrRecordNotFound)
+	})
+
+	Convey("Check delete of a missing record is reported but accepted in idempotent mode", t, func() {
+		err := checkDeleteResult(context.Background(), &mongo.DeleteResult{}, WriteModeIdempotent, "test", testTarget)
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Check delete of an existing record is successful", t, func() {
+		err := checkDeleteResult(context.Background(), &mongo.DeleteResult{DeletedCount: 1}, WriteModeStrict, "test", testTarget)
+		So(err, ShouldBeNil)
+	})
+}
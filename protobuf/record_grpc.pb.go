@@ -0,0 +1,260 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: record.proto
+
+package protobuf
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	RecordService_GetRDBRecordsStream_FullMethodName = "/protobuf.RecordService/GetRDBRecordsStream"
+	RecordService_GetRecord_FullMethodName           = "/protobuf.RecordService/GetRecord"
+	RecordService_ListRecords_FullMethodName         = "/protobuf.RecordService/ListRecords"
+	RecordService_QueryRecords_FullMethodName        = "/protobuf.RecordService/QueryRecords"
+)
+
+// RecordServiceClient is the client API for RecordService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RecordServiceClient interface {
+	// GetRDBRecordsStream streams the record changes applied to the RDB from the moment
+	// of the subscription, filtered by database and group
+	GetRDBRecordsStream(ctx context.Context, in *RecordFilter, opts ...grpc.CallOption) (RecordService_GetRDBRecordsStreamClient, error)
+	// GetRecord reads a single record
+	GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*Record, error)
+	// ListRecords reads the records of a group one page at a time, ordered by ID
+	ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*RecordPage, error)
+	// QueryRecords reads the records of a group matching a filter expression, such as
+	// `status = "active" and age >= 18`, one page at a time, ordered by ID
+	QueryRecords(ctx context.Context, in *QueryRecordsRequest, opts ...grpc.CallOption) (*RecordPage, error)
+}
+
+type recordServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRecordServiceClient(cc grpc.ClientConnInterface) RecordServiceClient {
+	return &recordServiceClient{cc}
+}
+
+func (c *recordServiceClient) GetRDBRecordsStream(ctx context.Context, in *RecordFilter, opts ...grpc.CallOption) (RecordService_GetRDBRecordsStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RecordService_ServiceDesc.Streams[0], RecordService_GetRDBRecordsStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &recordServiceGetRDBRecordsStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RecordService_GetRDBRecordsStreamClient interface {
+	Recv() (*RecordSet, error)
+	grpc.ClientStream
+}
+
+type recordServiceGetRDBRecordsStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *recordServiceGetRDBRecordsStreamClient) Recv() (*RecordSet, error) {
+	m := new(RecordSet)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *recordServiceClient) GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, RecordService_GetRecord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordServiceClient) ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*RecordPage, error) {
+	out := new(RecordPage)
+	err := c.cc.Invoke(ctx, RecordService_ListRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordServiceClient) QueryRecords(ctx context.Context, in *QueryRecordsRequest, opts ...grpc.CallOption) (*RecordPage, error) {
+	out := new(RecordPage)
+	err := c.cc.Invoke(ctx, RecordService_QueryRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecordServiceServer is the server API for RecordService service.
+// All implementations must embed UnimplementedRecordServiceServer
+// for forward compatibility
+type RecordServiceServer interface {
+	// GetRDBRecordsStream streams the record changes applied to the RDB from the moment
+	// of the subscription, filtered by database and group
+	GetRDBRecordsStream(*RecordFilter, RecordService_GetRDBRecordsStreamServer) error
+	// GetRecord reads a single record
+	GetRecord(context.Context, *GetRecordRequest) (*Record, error)
+	// ListRecords reads the records of a group one page at a time, ordered by ID
+	ListRecords(context.Context, *ListRecordsRequest) (*RecordPage, error)
+	// QueryRecords reads the records of a group matching a filter expression, such as
+	// `status = "active" and age >= 18`, one page at a time, ordered by ID
+	QueryRecords(context.Context, *QueryRecordsRequest) (*RecordPage, error)
+	mustEmbedUnimplementedRecordServiceServer()
+}
+
+// UnimplementedRecordServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRecordServiceServer struct {
+}
+
+func (UnimplementedRecordServiceServer) GetRDBRecordsStream(*RecordFilter, RecordService_GetRDBRecordsStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetRDBRecordsStream not implemented")
+}
+func (UnimplementedRecordServiceServer) GetRecord(context.Context, *GetRecordRequest) (*Record, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecord not implemented")
+}
+func (UnimplementedRecordServiceServer) ListRecords(context.Context, *ListRecordsRequest) (*RecordPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecords not implemented")
+}
+func (UnimplementedRecordServiceServer) QueryRecords(context.Context, *QueryRecordsRequest) (*RecordPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryRecords not implemented")
+}
+func (UnimplementedRecordServiceServer) mustEmbedUnimplementedRecordServiceServer() {}
+
+// UnsafeRecordServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RecordServiceServer will
+// result in compilation errors.
+type UnsafeRecordServiceServer interface {
+	mustEmbedUnimplementedRecordServiceServer()
+}
+
+func RegisterRecordServiceServer(s grpc.ServiceRegistrar, srv RecordServiceServer) {
+	s.RegisterService(&RecordService_ServiceDesc, srv)
+}
+
+func _RecordService_GetRDBRecordsStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RecordFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RecordServiceServer).GetRDBRecordsStream(m, &recordServiceGetRDBRecordsStreamServer{stream})
+}
+
+type RecordService_GetRDBRecordsStreamServer interface {
+	Send(*RecordSet) error
+	grpc.ServerStream
+}
+
+type recordServiceGetRDBRecordsStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *recordServiceGetRDBRecordsStreamServer) Send(m *RecordSet) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RecordService_GetRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordServiceServer).GetRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordService_GetRecord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordServiceServer).GetRecord(ctx, req.(*GetRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordService_ListRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordServiceServer).ListRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordService_ListRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordServiceServer).ListRecords(ctx, req.(*ListRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordService_QueryRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordServiceServer).QueryRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordService_QueryRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordServiceServer).QueryRecords(ctx, req.(*QueryRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RecordService_ServiceDesc is the grpc.ServiceDesc for RecordService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RecordService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protobuf.RecordService",
+	HandlerType: (*RecordServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRecord",
+			Handler:    _RecordService_GetRecord_Handler,
+		},
+		{
+			MethodName: "ListRecords",
+			Handler:    _RecordService_ListRecords_Handler,
+		},
+		{
+			MethodName: "QueryRecords",
+			Handler:    _RecordService_QueryRecords_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetRDBRecordsStream",
+			Handler:       _RecordService_GetRDBRecordsStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "record.proto",
+}
@@ -0,0 +1,97 @@
+package recordfeed
+
+import (
+	"sync"
+	rdb "xqledger/rdboperator/mongodb"
+	utils "xqledger/rdboperator/utils"
+)
+
+// Change is an event that has been applied to the RDB.
+type Change struct {
+	Event       utils.RecordEvent
+	AppliedTime int64
+}
+
+// Subscription receives the changes of a database and group from the moment it is
+// created. An empty DBName or Group matches every value. A subscriber that does not
+// keep up with the changes is dropped: Changes is closed and Overflowed reports true,
+// so the client knows changes were missed instead of silently losing them.
+type Subscription struct {
+	Changes    <-chan Change
+	changes    chan Change
+	dbName     string
+	group      string
+	overflowed bool
+	closed     bool
+}
+
+type feed struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+var changeFeed = &feed{subscribers: make(map[*Subscription]struct{})}
+
+// Subscribe registers a subscriber for the changes of dbName and group. buffer is
+// the number of changes that can be pending before the subscriber is dropped.
+func Subscribe(dbName string, group string, buffer int) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	changes := make(chan Change, buffer)
+	subscription := &Subscription{Changes: changes, changes: changes, dbName: dbName, group: group}
+	changeFeed.mu.Lock()
+	changeFeed.subscribers[subscription] = struct{}{}
+	changeFeed.mu.Unlock()
+	return subscription
+}
+
+// Close unregisters the subscription and closes its channel.
+func (s *Subscription) Close() {
+	changeFeed.mu.Lock()
+	defer changeFeed.mu.Unlock()
+	s.close()
+}
+
+// Overflowed reports whether the subscription was dropped for falling behind.
+func (s *Subscription) Overflowed() bool {
+	changeFeed.mu.Lock()
+	defer changeFeed.mu.Unlock()
+	return s.overflowed
+}
+
+// close must be called with the feed lock held.
+func (s *Subscription) close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(changeFeed.subscribers, s)
+	close(s.changes)
+}
+
+// matches compares the database and collection that the subscription and the event
+// resolve to, so names written to the same collection match each other.
+func (s *Subscription) matches(event utils.RecordEvent) bool {
+	dbName, colName := rdb.RecordLocation(event.DBName, event.Group)
+	subscribedDB, subscribedCol := rdb.RecordLocation(s.dbName, s.group)
+	return (s.dbName == "" || subscribedDB == dbName) && (s.group == "" || subscribedCol == colName)
+}
+
+// Publish hands an applied event to every matching subscriber without blocking.
+func Publish(event utils.RecordEvent) {
+	change := Change{Event: event, AppliedTime: utils.GetEpochNow()}
+	changeFeed.mu.Lock()
+	defer changeFeed.mu.Unlock()
+	for subscription := range changeFeed.subscribers {
+		if !subscription.matches(event) {
+			continue
+		}
+		select {
+		case subscription.changes <- change:
+		default:
+			subscription.overflowed = true
+			subscription.close()
+		}
+	}
+}
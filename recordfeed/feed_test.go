@@ -0,0 +1,54 @@
+package recordfeed
+
+import (
+	"testing"
+	utils "xqledger/rdboperator/utils"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPublish(t *testing.T) {
+	Convey("Check changes are delivered to the matching subscribers ", t, func() {
+		all := Subscribe("", "", 10)
+		defer all.Close()
+		db := Subscribe("TestRepository", "", 10)
+		defer db.Close()
+		group := Subscribe("TestRepository", "Users", 10)
+		defer group.Close()
+
+		Publish(utils.RecordEvent{Id: "1", DBName: "TestRepository", Group: "Users"})
+		Publish(utils.RecordEvent{Id: "2", DBName: "TestRepository", Group: "Orders"})
+		Publish(utils.RecordEvent{Id: "3", DBName: "Other", Group: "Users"})
+
+		So(len(all.Changes), ShouldEqual, 3)
+		So(len(db.Changes), ShouldEqual, 2)
+		So(len(group.Changes), ShouldEqual, 1)
+		change := <-group.Changes
+		So(change.Event.Id, ShouldEqual, "1")
+		So(change.AppliedTime, ShouldBeGreaterThan, 0)
+	})
+
+	Convey("Check events match subscribers by the collection they are written to ", t, func() {
+		main := Subscribe("Test.Repository", "main", 10)
+		defer main.Close()
+
+		Publish(utils.RecordEvent{Id: "1", DBName: "TestRepository"})
+		Publish(utils.RecordEvent{Id: "2", DBName: "TestRepository", Group: "Users"})
+
+		So(len(main.Changes), ShouldEqual, 1)
+		change := <-main.Changes
+		So(change.Event.Id, ShouldEqual, "1")
+	})
+
+	Convey("Check a subscriber that falls behind is dropped ", t, func() {
+		slow := Subscribe("", "", 1)
+		Publish(utils.RecordEvent{Id: "1"})
+		Publish(utils.RecordEvent{Id: "2"})
+		So(slow.Overflowed(), ShouldBeTrue)
+		_, open := <-slow.Changes
+		So(open, ShouldBeTrue)
+		_, open = <-slow.Changes
+		So(open, ShouldBeFalse)
+		slow.Close()
+	})
+}
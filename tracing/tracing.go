@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	configuration "xqledger/rdboperator/configuration"
+	utils "xqledger/rdboperator/utils"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const componentMessage = "Tracing"
+
+var config = configuration.GlobalConfiguration
+
+// Span exporters, configured in Tracing.Exporter
+const (
+	// ExporterNone keeps propagating the trace context without recording spans
+	ExporterNone = "none"
+	// ExporterOTLP sends the spans to an OTLP collector over gRPC
+	ExporterOTLP = "otlp"
+	// ExporterStdout prints the spans, for local testing
+	ExporterStdout = "stdout"
+)
+
+// Init installs the W3C trace context propagator and a tracer provider exporting the
+// spans as configured in Tracing. It returns the function that flushes the pending
+// spans and stops the provider on shutdown.
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	methodMsg := "Init"
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, strings.ToLower(config.Tracing.Exporter))
+	if err != nil {
+		utils.PrintLogError(err, componentMessage, methodMsg, "Error creating span exporter")
+		return nil, err
+	}
+	if exporter == nil {
+		utils.PrintLogInfo(componentMessage, methodMsg, "Span export disabled - Trace context is still propagated")
+		return func(context.Context) error { return nil }, nil
+	}
+	provider := NewProvider(exporter)
+	otel.SetTracerProvider(provider)
+	utils.PrintLogInfo(componentMessage, methodMsg, fmt.Sprintf("Exporting spans with the %s exporter", config.Tracing.Exporter))
+	return provider.Shutdown, nil
+}
+
+// NewProvider returns a tracer provider that batches the spans to exporter, sampled
+// with Tracing.Samplingratio. Tests use it with an in-memory exporter.
+func NewProvider(exporter sdktrace.SpanExporter) *sdktrace.TracerProvider {
+	serviceName := config.Tracing.Servicename
+	if len(serviceName) == 0 {
+		serviceName = "rdboperator"
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.Tracing.Samplingratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+}
+
+func newExporter(ctx context.Context, exporter string) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case ExporterOTLP:
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Tracing.Endpoint)}
+		if config.Tracing.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, options...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case ExporterNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown span exporter '%s'", exporter)
+	}
+}
+
+// Tracer returns the tracer of a component of the operator.
+func Tracer(component string) trace.Tracer {
+	return otel.Tracer("xqledger/rdboperator/" + component)
+}
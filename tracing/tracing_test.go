@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewExporter(t *testing.T) {
+	Convey("Check spans are not exported without an exporter ", t, func() {
+		exporter, err := newExporter(context.Background(), ExporterNone)
+		So(err, ShouldBeNil)
+		So(exporter, ShouldBeNil)
+	})
+
+	Convey("Check the stdout exporter is available for local testing ", t, func() {
+		exporter, err := newExporter(context.Background(), ExporterStdout)
+		So(err, ShouldBeNil)
+		So(exporter, ShouldNotBeNil)
+	})
+
+	Convey("Check unknown exporters are rejected ", t, func() {
+		_, err := newExporter(context.Background(), "zipkin")
+		So(err, ShouldNotBeNil)
+	})
+}
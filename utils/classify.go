@@ -0,0 +1,21 @@
+package utils
+
+// ClassifyEvents splits a batch of events into the ones that must be applied in
+// arrival order and the ones that can be applied in parallel. An event is serialised
+// when another event of the batch touches the same DB, and so possibly the same
+// record. Both sets keep the arrival order of the batch.
+func ClassifyEvents(events []RecordEvent) ClassiffiedEventsSet {
+	databases := make(map[string]int)
+	for _, event := range events {
+		databases[event.DBName]++
+	}
+	var set ClassiffiedEventsSet
+	for _, event := range events {
+		if databases[event.DBName] > 1 {
+			set.SyncEvents = append(set.SyncEvents, event)
+		} else {
+			set.ParEvents = append(set.ParEvents, event)
+		}
+	}
+	return set
+}
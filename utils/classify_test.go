@@ -0,0 +1,42 @@
+package utils
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+func classifiedIDs(events []RecordEvent) []string {
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.DBName+":"+event.Id+":"+event.OperationType)
+	}
+	return ids
+}
+
+func TestClassifyEvents(t *testing.T) {
+	Convey("Check events of the same DB are serialised in arrival order ", t, func() {
+		set := ClassifyEvents([]RecordEvent{
+			{DBName: "repo", Id: "a", OperationType: "new"},
+			{DBName: "other", Id: "b", OperationType: "new"},
+			{DBName: "repo", Id: "c", OperationType: "update"},
+			{DBName: "repo", Id: "a", OperationType: "update"},
+			{DBName: "third", Id: "a", OperationType: "delete"},
+		})
+		So(classifiedIDs(set.SyncEvents), ShouldResemble, []string{"repo:a:new", "repo:c:update", "repo:a:update"})
+		So(classifiedIDs(set.ParEvents), ShouldResemble, []string{"other:b:new", "third:a:delete"})
+	})
+	Convey("Check events without record ID are serialised with their DB ", t, func() {
+		set := ClassifyEvents([]RecordEvent{
+			{DBName: "repo", Id: "a", OperationType: "new"},
+			{DBName: "repo", OperationType: "delete"},
+			{DBName: "other", Id: "b", OperationType: "new"},
+		})
+		So(classifiedIDs(set.SyncEvents), ShouldResemble, []string{"repo:a:new", "repo::delete"})
+		So(classifiedIDs(set.ParEvents), ShouldResemble, []string{"other:b:new"})
+	})
+	Convey("Check an empty batch has no events ", t, func() {
+		set := ClassifyEvents(nil)
+		So(set.SyncEvents, ShouldBeEmpty)
+		So(set.ParEvents, ShouldBeEmpty)
+	})
+}
@@ -57,6 +57,16 @@ func GetEpochNow() int64 {
 	return time.Now().Unix()
 }
 
+// EpochToTime turns an epoch timestamp of a RecordEvent into a time. Timestamps are
+// epoch seconds, as returned by GetEpochNow, but producers sending milliseconds are
+// also accepted: no timestamp in seconds reaches 1e12 before the year 33000.
+func EpochToTime(epoch int64) time.Time {
+	if epoch >= 1e12 {
+		return time.UnixMilli(epoch)
+	}
+	return time.Unix(epoch, 0)
+}
+
 func TurnUnixTimestampToString(u int64) string {
 	uS := strconv.FormatInt(u, 10)
 	i, err := strconv.ParseInt(uS, 10, 64)
@@ -3,6 +3,7 @@ package utils
 
 import (	
 	"testing"
+	"time"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -69,4 +70,11 @@ func TestAddTimeToNowEpoch(t *testing.T) {
 		result := AddTimeToNowEpoch(1, 5, 21)
 		So(result, ShouldBeGreaterThan, 0)
 	})
-}
\ No newline at end of file
+}
+
+func TestEpochToTime(t *testing.T) {
+	Convey("Check epoch seconds and milliseconds are both accepted ", t, func() {
+		So(EpochToTime(1600000000).Equal(time.Unix(1600000000, 0)), ShouldBeTrue)
+		So(EpochToTime(1600000000123).Equal(time.UnixMilli(1600000000123)), ShouldBeTrue)
+	})
+}
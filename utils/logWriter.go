@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	config "xqledger/rdboperator/configuration"
 
 	logger "github.com/sirupsen/logrus"
@@ -36,3 +37,57 @@ func PrintLogInfo(comp string, phase string, message string) bool {
 	}).Info(message)
 	return true
 }
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID of an event.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFrom returns the correlation ID carried by ctx, or an empty string.
+func CorrelationIDFrom(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
+
+// contextFields adds the correlation ID in ctx, if any, to the fields of a log line.
+func contextFields(ctx context.Context, fields logger.Fields) logger.Fields {
+	if correlationID := CorrelationIDFrom(ctx); len(correlationID) > 0 {
+		fields["CorrelationID"] = correlationID
+	}
+	return fields
+}
+
+// PrintLogErrorContext is PrintLogError for the log lines of an event, tagged with
+// the correlation ID in ctx.
+func PrintLogErrorContext(ctx context.Context, err error, comp string, phase string, errorMessage string) bool {
+	logger.WithFields(contextFields(ctx, logger.Fields{
+		"Time":      GetFormattedNow(),
+		"Component": comp,
+		"Phase":     phase,
+		"Error":     err,
+	})).Error(errorMessage)
+	return true
+}
+
+// PrintLogWarnContext is PrintLogWarn tagged with the correlation ID in ctx.
+func PrintLogWarnContext(ctx context.Context, err error, comp string, phase string, errorMessage string) bool {
+	logger.WithFields(contextFields(ctx, logger.Fields{
+		"Time":      GetFormattedNow(),
+		"Component": comp,
+		"Phase":     phase,
+		"Error":     err,
+	})).Warn(errorMessage)
+	return true
+}
+
+// PrintLogInfoContext is PrintLogInfo tagged with the correlation ID in ctx.
+func PrintLogInfoContext(ctx context.Context, comp string, phase string, message string) bool {
+	logger.WithFields(contextFields(ctx, logger.Fields{
+		"Time":      GetFormattedNow(),
+		"Component": comp,
+		"Phase":     phase,
+	})).Info(message)
+	return true
+}
@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"testing"
 	. "github.com/smartystreets/goconvey/convey"
+	logger "github.com/sirupsen/logrus"
 )
 
 const component = "test"
@@ -32,4 +34,21 @@ func TestPrintLogInfo(t *testing.T) {
 		result := PrintLogInfo(component, phase, message)
 		So(result, ShouldBeTrue)
 	})
-}
\ No newline at end of file
+}
+
+func TestCorrelationID(t *testing.T) {
+	Convey("Check the correlation ID is carried by the context ", t, func() {
+		So(CorrelationIDFrom(context.Background()), ShouldEqual, "")
+		ctx := WithCorrelationID(context.Background(), "abc")
+		So(CorrelationIDFrom(ctx), ShouldEqual, "abc")
+		So(contextFields(ctx, logger.Fields{})["CorrelationID"], ShouldEqual, "abc")
+		So(contextFields(context.Background(), logger.Fields{}), ShouldNotContainKey, "CorrelationID")
+	})
+
+	Convey("Sends formatted logs tagged with the correlation ID ", t, func() {
+		ctx := WithCorrelationID(context.Background(), "abc")
+		So(PrintLogErrorContext(ctx, errors.New("fake error"), component, phase, message), ShouldBeTrue)
+		So(PrintLogWarnContext(ctx, errors.New("fake error"), component, phase, message), ShouldBeTrue)
+		So(PrintLogInfoContext(ctx, component, phase, message), ShouldBeTrue)
+	})
+}
@@ -4,12 +4,30 @@ package utils
 const Event_topic_received_ok = "EVENT TOPIC RECEIVED OK"
 const Event_topic_received_fail = "EVENT TOPIC RECEIVED FAIL"
 const Event_topic_received_unacceptable = "EVENT TOPIC RECEIVED UNACCEPTABLE"
+const Event_apply_failed = "EVENT APPLY FAILED"
+const Event_commit_failed = "EVENT OFFSET COMMIT FAILED"
+const Event_dead_letter_sent = "EVENT SENT TO DEAD LETTER TOPIC"
+const Event_dead_letter_failed = "EVENT DEAD LETTER DELIVERY FAILED"
+const Event_result_failed = "EVENT RESULT DELIVERY FAILED"
 
 const Error_unmarshalling_RDB = "RDB UNMARSHAL ERROR"
 const Error_inserting_record_in_RDB = "RDB INSERTION RECORD ERROR"
 const Error_updating_record_in_RDB = "RDB UPDATE RECORD ERROR"
 const Error_deletion_record_in_RDB = "RDB DELETE RECORD ERROR"
+const Error_transient_RDB = "RDB TRANSIENT ERROR"
+const Error_update_missing_record_in_RDB = "RDB UPDATE MISSING RECORD - ID '%s' - Database '%s' - Collection '%s'"
+const Error_delete_missing_record_in_RDB = "RDB DELETE MISSING RECORD - ID '%s' - Database '%s' - Collection '%s'"
+const Error_patch_test_failed_in_RDB = "RDB PATCH TEST FAILED - ID '%s' - Database '%s' - Collection '%s'"
+const Error_recordset_RDB = "RDB RECORD SET ERROR - NO EVENT APPLIED"
+const Recordset_not_atomic = "RDB WITHOUT TRANSACTIONS - RECORD SET REJECTED"
 
 const Successful_insertion = "RECORD INSERTED OK - ID '%s' - Database '%s' - Collection '%s'"
 const Successful_update = "RECORD UPDATED OK - ID '%s' - Database '%s' - Collection '%s'"
+const Successful_upsert = "RECORD UPSERTED OK - ID '%s' - Database '%s' - Collection '%s'"
+const Successful_recordset = "RECORD SET APPLIED OK - %d events"
+const Unchanged_update = "RECORD UNCHANGED BY UPDATE - ID '%s' - Database '%s' - Collection '%s'"
+const Existing_record_kept = "RECORD ALREADY INSERTED - ID '%s' - Database '%s' - Collection '%s'"
+const Existing_record_replaced = "RECORD ALREADY INSERTED, REPLACED - ID '%s' - Database '%s' - Collection '%s'"
+const Missing_record_not_deleted = "RECORD TO DELETE NOT FOUND, NOTHING REMOVED - ID '%s' - Database '%s' - Collection '%s'"
+const Missing_record_skipped = "RECORD TO UPDATE NOT FOUND, SKIPPED - ID '%s' - Database '%s' - Collection '%s'"
 const Successful_delete = "RECORD DELETED OK - with ID '%s' - Database '%s' - Collection '%s'"
\ No newline at end of file
@@ -5,15 +5,39 @@ type RecordEvent struct {
 	Group string `json:"group"` // Name of the Git tree/folder
 	DBName string `json:"dbname"` // DB name mapped to Git repo
 	User string `json:"user"` // email of the individual performing the change
-	OperationType string `json:"operation_type"` // Values: (new | update | delete)
+	OperationType string `json:"operation_type"` // Values: (new | update | delete | merge | patch)
 	SendingTime int64 `json:"sending_time"` // Time of sending by the client
 	ReceptionTime int64 `json:"reception_time"` // Time of the reception by the API
 	ProcessingTime int64 `json:"processing_time"` // Time of processing by the Git Operator
 	Priority string `json:"priority"`  // API can qualify an event with a priority to be considered in concurrent writing decisions (HIGH | MEDIUM | LOW)
-	RecordContent string `json:"record_content"` // empty if op OperationType == delete | update
+	RecordContent string `json:"record_content"` // empty if op OperationType == delete | update. JSON Merge Patch (RFC 7386) if merge, JSON Patch (RFC 6902) if patch
 	Status string `json:"status"` // PENDING | NOTVALID | INCOMPLETE | COMPLETE
 }
 
+// Statuses of a RecordEvent
+const (
+	StatusPending    = "PENDING"
+	StatusNotValid   = "NOTVALID"
+	StatusIncomplete = "INCOMPLETE"
+	StatusComplete   = "COMPLETE"
+)
+
+type RecordResultEvent struct {
+	Id   string `json:"id"` // Name of the file/record in the database
+	Group string `json:"group"` // Name of the Git tree/folder
+	DBName string `json:"dbname"` // DB name mapped to Git repo
+	User string `json:"user"` // email of the individual performing the change
+	OperationType string `json:"operation_type"` // Values: (new | update | delete | merge | patch)
+	Status string `json:"status"` // COMPLETE: visible in the RDB | NOTVALID: will never be applied | INCOMPLETE: not applied, may succeed if sent again
+	Reason string `json:"reason"` // empty if Status == COMPLETE
+	Attempts int `json:"attempts"` // Number of apply attempts, 0 if the event could not be read
+	SendingTime int64 `json:"sending_time"` // Time of sending by the client
+	ReceptionTime int64 `json:"reception_time"` // Time of the reception by the API
+	ProcessingTime int64 `json:"processing_time"` // Time of processing by the Git Operator
+	ResultTime int64 `json:"result_time"` // Time of the outcome in the RDB Operator
+	CorrelationID string `json:"correlation_id"` // ID of the event in the logs of the RDB Operator
+}
+
 type RecordSet struct {
 	Records []RecordEvent `json:"recordset"`
 }
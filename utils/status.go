@@ -0,0 +1,48 @@
+package utils
+
+import "strings"
+
+// Actions taken on a RecordEvent depending on its status
+const (
+	// StatusActionApply writes the event to the RDB
+	StatusActionApply = "apply"
+	// StatusActionSkip leaves the RDB untouched and commits the event
+	StatusActionSkip = "skip"
+	// StatusActionReview routes the event to the review topic without applying it
+	StatusActionReview = "review"
+)
+
+// StatusAction returns what to do with an event in the given status, following the
+// rules in Status.Apply, Status.Skip and Status.Review. Events without a status are
+// taken as PENDING, and statuses not covered by any rule are routed for review.
+// Without any rule configured every event is applied, as before statuses were read.
+func StatusAction(status string) string {
+	return statusAction(status, configuration.Status.Apply, configuration.Status.Skip, configuration.Status.Review)
+}
+
+func statusAction(status string, apply []string, skip []string, review []string) string {
+	if len(apply) == 0 && len(skip) == 0 && len(review) == 0 {
+		return StatusActionApply
+	}
+	status = strings.ToUpper(strings.TrimSpace(status))
+	if len(status) == 0 {
+		status = StatusPending
+	}
+	switch {
+	case containsStatus(apply, status):
+		return StatusActionApply
+	case containsStatus(skip, status):
+		return StatusActionSkip
+	default:
+		return StatusActionReview
+	}
+}
+
+func containsStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if strings.EqualFold(strings.TrimSpace(s), status) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+)
+
+var apply = []string{StatusPending, StatusComplete}
+var skip = []string{StatusNotValid}
+var review = []string{StatusIncomplete}
+
+func TestStatusAction(t *testing.T) {
+	Convey("Check events are applied, skipped or reviewed by status ", t, func() {
+		So(statusAction("PENDING", apply, skip, review), ShouldEqual, StatusActionApply)
+		So(statusAction("complete", apply, skip, review), ShouldEqual, StatusActionApply)
+		So(statusAction("NOTVALID", apply, skip, review), ShouldEqual, StatusActionSkip)
+		So(statusAction("INCOMPLETE", apply, skip, review), ShouldEqual, StatusActionReview)
+	})
+	Convey("Check events without status are taken as PENDING ", t, func() {
+		So(statusAction(" ", apply, skip, review), ShouldEqual, StatusActionApply)
+	})
+	Convey("Check unknown statuses are routed for review ", t, func() {
+		So(statusAction("ARCHIVED", apply, skip, review), ShouldEqual, StatusActionReview)
+	})
+	Convey("Check every event is applied when no rule is configured ", t, func() {
+		So(statusAction("INCOMPLETE", nil, nil, nil), ShouldEqual, StatusActionApply)
+		So(statusAction("ARCHIVED", []string{}, []string{}, []string{}), ShouldEqual, StatusActionApply)
+	})
+	Convey("Check the configured rules are used ", t, func() {
+		So(StatusAction("NOTVALID"), ShouldEqual, StatusActionSkip)
+		So(StatusAction("PENDING"), ShouldEqual, StatusActionApply)
+	})
+}